@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+
+	"github.com/akarasz/pajthy-backend/event"
+	"github.com/akarasz/pajthy-backend/store"
+)
+
+// HandleLambda serves the $connect, $disconnect and $default routes of an API
+// Gateway websocket API. Clients connect with the session and token query
+// parameters, the token decides if they subscribe as voter, controller or
+// observer.
+func HandleLambda(ctx context.Context, in *events.APIGatewayWebsocketProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	c, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	rc := in.RequestContext
+	e := event.NewAPIGateway(&c,
+		"https://"+rc.DomainName+"/"+rc.Stage,
+		event.NewDynamoDBConnections(&c, os.Getenv("CONNECTIONS_TABLE_NAME"), connectionsIndex()))
+
+	switch rc.RouteKey {
+	case "$connect":
+		ttl := 24 * time.Hour
+		if v := os.Getenv("SESSION_TTL"); v != "" {
+			if ttl, err = time.ParseDuration(v); err != nil {
+				return nil, err
+			}
+		}
+		s := store.NewDynamoDB(&c, os.Getenv("DYNAMO_TABLE_NAME"), store.WithTTL(ttl))
+
+		return connect(s, e, in.QueryStringParameters["session"], in.QueryStringParameters["token"], rc.ConnectionID)
+	case "$disconnect":
+		if err := e.Unsubscribe("", rc.ConnectionID); err != nil {
+			return nil, err
+		}
+		return respond(http.StatusOK), nil
+	default:
+		// clients have nothing to say, messages are ignored like on the
+		// websockets of the server
+		return respond(http.StatusOK), nil
+	}
+}
+
+func connect(s store.Store, e event.Event, sessionID, token, connectionID string) (*events.APIGatewayProxyResponse, error) {
+	loaded, err := s.Load(sessionID)
+	if err == store.ErrNotExists {
+		return respond(http.StatusNotFound), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var role event.Role
+	if loaded.Data.IsController(token) {
+		role = event.Controller
+	} else if _, ok := loaded.Data.ParticipantFor(token); ok {
+		role = event.Voter
+	} else if _, ok := loaded.Data.ObserverFor(token); ok {
+		role = event.Observer
+	} else {
+		return respond(http.StatusUnauthorized), nil
+	}
+
+	if _, err := e.Subscribe(sessionID, role, connectionID); err != nil {
+		return nil, err
+	}
+
+	log.Printf("connect %q", sessionID)
+	return respond(http.StatusOK), nil
+}
+
+func respond(code int) *events.APIGatewayProxyResponse {
+	return &events.APIGatewayProxyResponse{StatusCode: code}
+}
+
+func connectionsIndex() string {
+	if v := os.Getenv("CONNECTIONS_INDEX_NAME"); v != "" {
+		return v
+	}
+	return event.DefaultConnectionsIndex
+}
+
+func main() {
+	lambda.Start(HandleLambda)
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 
 	"github.com/akarasz/pajthy-backend/event"
@@ -59,7 +60,15 @@ func HandleLambda(ctx context.Context, in *Request) (*Response, error) {
 		return nil, err
 	}
 
-	h := handler.New(store.NewDynamoDB(&c, os.Getenv("DYNAMO_TABLE_NAME")), event.New())
+	ttl := 24 * time.Hour
+	if v := os.Getenv("SESSION_TTL"); v != "" {
+		if ttl, err = time.ParseDuration(v); err != nil {
+			return nil, err
+		}
+	}
+
+	s := store.NewDynamoDB(&c, os.Getenv("DYNAMO_TABLE_NAME"), store.WithTTL(ttl))
+	h := handler.New(s, newEvent(&c))
 	h.ServeHTTP(rr, req)
 
 	headers := map[string]string{}
@@ -74,6 +83,23 @@ func HandleLambda(ctx context.Context, in *Request) (*Response, error) {
 	}, nil
 }
 
+// newEvent pushes the events to the clients of the websocket API when its
+// endpoint is configured. Otherwise nobody could receive them.
+func newEvent(c *aws.Config) event.Event {
+	endpoint := os.Getenv("WEBSOCKET_ENDPOINT")
+	if endpoint == "" {
+		return event.NewInMemory()
+	}
+
+	index := os.Getenv("CONNECTIONS_INDEX_NAME")
+	if index == "" {
+		index = event.DefaultConnectionsIndex
+	}
+
+	return event.NewAPIGateway(c, endpoint,
+		event.NewDynamoDBConnections(c, os.Getenv("CONNECTIONS_TABLE_NAME"), index))
+}
+
 func main() {
 	rand.Seed(time.Now().UnixNano())
 
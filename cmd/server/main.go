@@ -1,21 +1,114 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"expvar"
+	"flag"
+	"fmt"
 	"log"
 	"math/rand"
 	"net/http"
 	"time"
 
+	"github.com/go-redis/redis/v8"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+
 	"github.com/akarasz/pajthy-backend/event"
 	"github.com/akarasz/pajthy-backend/handler"
 	"github.com/akarasz/pajthy-backend/store"
 )
 
+var (
+	storeKind = flag.String("store", "", "where sessions are kept: memory, bolt, redis or sql (default bolt when -data-dir is set, memory otherwise)")
+	dataDir   = flag.String("data-dir", "", "directory of the bolt store")
+	redisAddr = flag.String("redis-addr", "localhost:6379", "address of the redis server")
+	sqlDriver = flag.String("sql-driver", "sqlite3", "database driver of the sql store: sqlite3 or postgres")
+	sqlDSN    = flag.String("sql-dsn", "pajthy.db", "data source name of the sql store")
+	broker    = flag.String("broker", "memory", "how events reach the subscribers: memory or redis to share them between instances")
+	ttl       = flag.Duration("ttl", 24*time.Hour, "how long inactive sessions are kept, 0 keeps them forever")
+	buffer    = flag.Int("event-buffer", 16, "how many events are queued for a subscriber before it counts as slow")
+	slow      = flag.String("slow-subscribers", "disconnect", "what happens with slow subscribers: disconnect or drop their events")
+	metrics   = flag.String("metrics-addr", "", "address serving the expvar metrics on /debug/vars, empty disables it")
+)
+
 func main() {
+	flag.Parse()
 	rand.Seed(time.Now().UnixNano())
 
-	s := store.NewInMemory()
-	e := event.New()
+	s, err := newStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+	e, err := newEvent()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go handler.Janitor(context.Background(), s, e, time.Minute)
+
+	if *metrics != "" {
+		if r, ok := e.(event.Reporter); ok {
+			expvar.Publish("events", expvar.Func(func() interface{} {
+				return r.Stats()
+			}))
+		}
+		go func() {
+			log.Fatal(http.ListenAndServe(*metrics, nil))
+		}()
+	}
 
 	log.Fatal(http.ListenAndServe(":8000", handler.New(s, e)))
 }
+
+func newStore() (store.Store, error) {
+	opts := []store.Option{store.WithTTL(*ttl)}
+
+	kind := *storeKind
+	if kind == "" {
+		kind = "memory"
+		if *dataDir != "" {
+			kind = "bolt"
+		}
+	}
+
+	switch kind {
+	case "memory":
+		return store.NewInMemory(opts...), nil
+	case "bolt":
+		return store.NewBolt(*dataDir, opts...)
+	case "redis":
+		return store.NewRedis(&redis.Options{Addr: *redisAddr}, opts...), nil
+	case "sql":
+		db, err := sql.Open(*sqlDriver, *sqlDSN)
+		if err != nil {
+			return nil, err
+		}
+		return store.NewSQL(db, opts...)
+	default:
+		return nil, fmt.Errorf("unknown store %q", kind)
+	}
+}
+
+func newEvent() (event.Event, error) {
+	opts := []event.Option{event.WithBufferSize(*buffer)}
+
+	switch *slow {
+	case "disconnect":
+		opts = append(opts, event.WithPolicy(event.Disconnect))
+	case "drop":
+		opts = append(opts, event.WithPolicy(event.Drop))
+	default:
+		return nil, fmt.Errorf("unknown slow subscriber policy %q", *slow)
+	}
+
+	switch *broker {
+	case "memory":
+		return event.NewInMemory(opts...), nil
+	case "redis":
+		return event.NewRedis(&redis.Options{Addr: *redisAddr}, opts...), nil
+	default:
+		return nil, fmt.Errorf("unknown broker %q", *broker)
+	}
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidSettings = errors.New("not valid settings")
+
+// AutoClose is the policy deciding when a round is closed without the
+// controller stopping it.
+type AutoClose string
+
+const (
+	// AllVoted closes when everybody not sitting out has voted. It is the
+	// default.
+	AllVoted = AutoClose("")
+	// Never leaves the round open until the controller stops it.
+	Never = AutoClose("never")
+	// ConnectedVoted closes when everybody connected and not sitting out
+	// has voted.
+	ConnectedVoted = AutoClose("connected")
+	// Quorum closes when the Quorum percent of the participants not sitting
+	// out has voted.
+	Quorum = AutoClose("quorum")
+	// Timer closes only at the deadline. Rounds started without a duration
+	// get the Timer of the settings.
+	Timer = AutoClose("timer")
+)
+
+// Validate tells if the settings can be used.
+func (s *Settings) Validate() error {
+	switch s.AutoClose {
+	case AllVoted, Never, ConnectedVoted:
+	case Quorum:
+		if s.Quorum < 1 || s.Quorum > 100 {
+			return ErrInvalidSettings
+		}
+	case Timer:
+		if s.TimerDuration() <= 0 {
+			return ErrInvalidSettings
+		}
+	default:
+		return ErrInvalidSettings
+	}
+
+	if s.Grace != "" && s.GraceDuration() <= 0 {
+		return ErrInvalidSettings
+	}
+	return nil
+}
+
+// TimerDuration returns the duration of the rounds for the timer policy, zero
+// when there is none.
+func (s *Settings) TimerDuration() time.Duration {
+	return parseDuration(s.Timer)
+}
+
+// GraceDuration returns the time given before closing the round by the
+// policy, zero when there is none.
+func (s *Settings) GraceDuration() time.Duration {
+	return parseDuration(s.Grace)
+}
+
+func parseDuration(v string) time.Duration {
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// ShouldClose tells if the open round is to be closed by the auto-close
+// policy of the session. Connected are the participants having a connection,
+// nil when it is not known. When none of the ones voting is connected, like
+// when they vote over plain HTTP, everybody is waited for.
+func (s *Session) ShouldClose(connected map[string]bool) bool {
+	if !s.Open || len(s.Votes) == 0 {
+		return false
+	}
+
+	switch s.Settings.AutoClose {
+	case AllVoted:
+		return s.Complete()
+	case ConnectedVoted:
+		waiting := false
+		for _, p := range s.Participants {
+			if !connected[p] || s.IsSittingOut(p) {
+				continue
+			}
+			if !s.hasVoted(p) {
+				return false
+			}
+			waiting = true
+		}
+		if !waiting {
+			return s.Complete()
+		}
+		return true
+	case Quorum:
+		active, voted := 0, 0
+		for _, p := range s.Participants {
+			if s.IsSittingOut(p) {
+				continue
+			}
+			active++
+			if s.hasVoted(p) {
+				voted++
+			}
+		}
+		return voted*100 >= active*s.Settings.Quorum
+	default:
+		return false
+	}
+}
+
+// Settle applies the auto-close policy after the votes or the participants
+// changed. The round is closed, or with a grace period it is set to be closed
+// at the end of it. The closing is called off when the policy does not hold
+// anymore.
+func (s *Session) Settle(now time.Time, connected map[string]bool) {
+	if !s.ShouldClose(connected) {
+		s.Closing = time.Time{}
+		return
+	}
+
+	grace := s.Settings.GraceDuration()
+	if grace <= 0 {
+		s.Close(now)
+		return
+	}
+	if s.Closing.IsZero() {
+		s.Closing = now.Add(grace)
+	}
+}
@@ -0,0 +1,115 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akarasz/pajthy-backend/domain"
+)
+
+func TestShouldClose(t *testing.T) {
+	newSession := func(settings domain.Settings, votes map[string]string, sittingOut ...string) *domain.Session {
+		s := domain.NewSession()
+		s.Participants = []string{"Alice", "Bob", "Carol", "Dave"}
+		s.Open = true
+		s.Votes = votes
+		s.SittingOut = sittingOut
+		s.Settings = settings
+		return s
+	}
+	three := map[string]string{"Alice": "1", "Bob": "2", "Carol": "3"}
+	all := map[string]string{"Alice": "1", "Bob": "2", "Carol": "3", "Dave": "5"}
+
+	tests := []struct {
+		name      string
+		session   *domain.Session
+		connected map[string]bool
+		want      bool
+	}{
+		{"all voted", newSession(domain.Settings{}, all), nil, true},
+		{"all without one", newSession(domain.Settings{}, three), nil, false},
+		{"all sitting out", newSession(domain.Settings{}, three, "Dave"), nil, true},
+		{"all without votes", newSession(domain.Settings{}, map[string]string{}, "Alice", "Bob", "Carol", "Dave"), nil, false},
+
+		{"never", newSession(domain.Settings{AutoClose: domain.Never}, all), nil, false},
+
+		{"connected voted",
+			newSession(domain.Settings{AutoClose: domain.ConnectedVoted}, three),
+			map[string]bool{"Alice": true, "Bob": true, "Carol": true, "Dave": false}, true},
+		{"connected without one",
+			newSession(domain.Settings{AutoClose: domain.ConnectedVoted}, three),
+			map[string]bool{"Alice": true, "Dave": true}, false},
+		{"connected not known",
+			newSession(domain.Settings{AutoClose: domain.ConnectedVoted}, three), nil, false},
+		{"connected nobody",
+			newSession(domain.Settings{AutoClose: domain.ConnectedVoted}, three), map[string]bool{}, false},
+		{"connected nobody all voted",
+			newSession(domain.Settings{AutoClose: domain.ConnectedVoted}, all), map[string]bool{}, true},
+		{"connected only sitting out",
+			newSession(domain.Settings{AutoClose: domain.ConnectedVoted}, three, "Dave"), map[string]bool{"Dave": true}, true},
+
+		{"quorum reached", newSession(domain.Settings{AutoClose: domain.Quorum, Quorum: 75}, three), nil, true},
+		{"quorum not reached", newSession(domain.Settings{AutoClose: domain.Quorum, Quorum: 80}, three), nil, false},
+		{"quorum sitting out", newSession(domain.Settings{AutoClose: domain.Quorum, Quorum: 100}, three, "Dave"), nil, true},
+
+		{"timer", newSession(domain.Settings{AutoClose: domain.Timer, Timer: "1m"}, all), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Exactly(t, tt.want, tt.session.ShouldClose(tt.connected))
+		})
+	}
+
+	// closed rounds are left alone
+	s := newSession(domain.Settings{}, all)
+	s.Open = false
+	assert.False(t, s.ShouldClose(nil))
+}
+
+func TestSettings_Validate(t *testing.T) {
+	assert.NoError(t, (&domain.Settings{}).Validate())
+	assert.NoError(t, (&domain.Settings{AutoClose: domain.Quorum, Quorum: 60}).Validate())
+	assert.NoError(t, (&domain.Settings{AutoClose: domain.Timer, Timer: "90s"}).Validate())
+	assert.Exactly(t, 90*time.Second, (&domain.Settings{Timer: "90s"}).TimerDuration())
+
+	assert.Error(t, (&domain.Settings{AutoClose: "sometimes"}).Validate())
+	assert.Error(t, (&domain.Settings{AutoClose: domain.Quorum}).Validate())
+	assert.Error(t, (&domain.Settings{AutoClose: domain.Quorum, Quorum: 101}).Validate())
+	assert.Error(t, (&domain.Settings{AutoClose: domain.Timer, Timer: "soon"}).Validate())
+}
+
+func TestSettle(t *testing.T) {
+	now := time.Now()
+	s := domain.NewSession()
+	s.Participants = []string{"Alice"}
+	s.Open = true
+	s.Opened = now
+
+	// not closing while the policy does not hold
+	s.Settle(now, nil)
+	assert.True(t, s.Open)
+	assert.True(t, s.Closing.IsZero())
+
+	// closing after the grace period
+	s.Settings.Grace = "10s"
+	s.Votes = map[string]string{"Alice": "1"}
+	s.Settle(now, nil)
+	assert.True(t, s.Open)
+	assert.Exactly(t, now.Add(10*time.Second), s.Closing)
+	assert.False(t, s.Expired(now))
+	assert.True(t, s.Expired(now.Add(10*time.Second)))
+
+	// called off by a retracted vote
+	s.Votes = map[string]string{}
+	s.Settle(now, nil)
+	assert.True(t, s.Closing.IsZero())
+
+	// closing right away without a grace period
+	s.Settings.Grace = ""
+	s.Votes = map[string]string{"Alice": "1"}
+	s.Settle(now, nil)
+	assert.False(t, s.Open)
+	assert.Exactly(t, now, s.Closed)
+}
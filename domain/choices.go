@@ -0,0 +1,60 @@
+package domain
+
+import "errors"
+
+var ErrInvalidChoices = errors.New("not valid choices")
+
+// SetChoices replaces the choices, keeping the cards of the ones staying.
+// The choices have to be unique and not empty.
+func (s *Session) SetChoices(choices []string) error {
+	seen := make(map[string]bool, len(choices))
+	for _, c := range choices {
+		if c == "" || seen[c] {
+			return ErrInvalidChoices
+		}
+		seen[c] = true
+	}
+
+	deck := []Card{}
+	for _, c := range s.Deck {
+		if seen[c.Choice] {
+			deck = append(deck, c)
+		}
+	}
+
+	s.Choices = append([]string{}, choices...)
+	s.Deck = deck
+	return nil
+}
+
+// AddChoice appends the choice to the end.
+func (s *Session) AddChoice(choice string) error {
+	return s.SetChoices(append(append([]string{}, s.Choices...), choice))
+}
+
+// RemoveChoice removes the nth choice together with its card.
+func (s *Session) RemoveChoice(n int) error {
+	choices := append([]string{}, s.Choices[:n]...)
+	return s.SetChoices(append(choices, s.Choices[n+1:]...))
+}
+
+// ReorderChoices rearranges the choices so that the one at order[i] is moved
+// to position i.
+func (s *Session) ReorderChoices(order []int) error {
+	if len(order) != len(s.Choices) {
+		return ErrInvalidOrder
+	}
+
+	seen := make([]bool, len(order))
+	choices := make([]string, len(order))
+	for i, from := range order {
+		if from < 0 || from >= len(s.Choices) || seen[from] {
+			return ErrInvalidOrder
+		}
+		seen[from] = true
+
+		choices[i] = s.Choices[from]
+	}
+
+	return s.SetChoices(choices)
+}
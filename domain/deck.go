@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Card is the metadata of a choice.
+type Card struct {
+	Choice string
+	Label  string
+
+	// Value is used by the statistics when the card Counts in them.
+	Value  float64
+	Counts bool
+}
+
+var specialCards = []Card{
+	{Choice: "?", Label: "?"},
+	{Choice: "coffee", Label: "☕"},
+}
+
+var decks = map[string][]Card{
+	"fibonacci": numericCards(
+		"0", "1", "2", "3", "5", "8", "13", "21", "34", "55", "89"),
+	"modified-fibonacci": numericCards(
+		"0", "0.5", "1", "2", "3", "5", "8", "13", "20", "40", "100"),
+	"powers-of-two": numericCards(
+		"0", "1", "2", "4", "8", "16", "32", "64"),
+	"t-shirt": {
+		{Choice: "XS", Label: "XS", Value: 1, Counts: true},
+		{Choice: "S", Label: "S", Value: 2, Counts: true},
+		{Choice: "M", Label: "M", Value: 3, Counts: true},
+		{Choice: "L", Label: "L", Value: 4, Counts: true},
+		{Choice: "XL", Label: "XL", Value: 5, Counts: true},
+		{Choice: "XXL", Label: "XXL", Value: 6, Counts: true},
+	},
+	"hours": numericCards(
+		"1", "2", "4", "8", "16", "24", "40"),
+}
+
+// Deck returns the cards of the named preset, ending with the special ones.
+func Deck(name string) ([]Card, bool) {
+	cards, ok := decks[name]
+	if !ok {
+		return nil, false
+	}
+
+	res := make([]Card, 0, len(cards)+len(specialCards))
+	res = append(res, cards...)
+	return append(res, specialCards...), true
+}
+
+// Choices returns the choices of the cards in order.
+func Choices(cards []Card) []string {
+	res := make([]string, len(cards))
+	for i, c := range cards {
+		res[i] = c.Choice
+	}
+	return res
+}
+
+// Card returns the metadata of the choice. Choices without one in the deck
+// count in the statistics when they are numbers.
+func (s *Session) Card(choice string) Card {
+	for _, c := range s.Deck {
+		if c.Choice == choice {
+			return c
+		}
+	}
+	return newCard(choice)
+}
+
+// SortChoices orders the choices by the value of their cards, keeping the
+// ones not counting in the statistics last.
+func (s *Session) SortChoices(choices []string) {
+	sort.SliceStable(choices, func(i, j int) bool {
+		a, b := s.Card(choices[i]), s.Card(choices[j])
+		if a.Counts != b.Counts {
+			return a.Counts
+		}
+		return a.Counts && a.Value < b.Value
+	})
+}
+
+func newCard(choice string) Card {
+	res := Card{Choice: choice, Label: choice}
+	if v, err := strconv.ParseFloat(choice, 64); err == nil {
+		res.Value = v
+		res.Counts = true
+	}
+	return res
+}
+
+func numericCards(choices ...string) []Card {
+	res := make([]Card, len(choices))
+	for i, c := range choices {
+		res[i] = newCard(c)
+	}
+	return res
+}
@@ -1,5 +1,12 @@
 package domain
 
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+)
+
 type Vote struct {
 	Participant string
 	Choice      string
@@ -10,6 +17,42 @@ type Session struct {
 	Participants []string
 	Votes        map[string]string
 	Open         bool
+
+	// Voters are the participants having a vote in the current round when
+	// the session is anonymous, as the votes are not kept by their names.
+	Voters []string
+
+	// SittingOut are the participants not voting until they are back, so the
+	// rounds are completed without them.
+	SittingOut []string
+
+	// Deck has the metadata of the choices. Choices missing from it get
+	// the one derived from the choice itself.
+	Deck []Card
+
+	Topic  string
+	Opened time.Time
+	Closed time.Time
+	Rounds []Round
+
+	// Deadline is when the current round gets closed, zero if it is open
+	// until stopped.
+	Deadline time.Time
+	// Closing is when the current round gets closed by the auto-close
+	// policy after the grace period, zero if it is not about to.
+	Closing time.Time
+
+	Items   []Item
+	Current int
+
+	// Observers watch the session without voting.
+	Observers []string
+
+	Settings Settings
+
+	ControllerHash    string
+	ParticipantHashes map[string]string
+	ObserverHashes    map[string]string
 }
 
 func NewSession() *Session {
@@ -17,6 +60,57 @@ func NewSession() *Session {
 		Choices:      []string{},
 		Participants: []string{},
 		Votes:        map[string]string{},
+		Voters:       []string{},
+		Deck:         []Card{},
+		SittingOut:   []string{},
 		Open:         false,
+		Rounds:       []Round{},
+		Items:        []Item{},
+		Current:      -1,
+		Observers:    []string{},
+
+		ParticipantHashes: map[string]string{},
+		ObserverHashes:    map[string]string{},
 	}
 }
+
+// HashToken returns the representation of a secret token that is safe to
+// persist.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Session) IsController(token string) bool {
+	return s.ControllerHash != "" && matchesHash(token, s.ControllerHash)
+}
+
+func (s *Session) IsParticipant(name string, token string) bool {
+	hash, ok := s.ParticipantHashes[name]
+	return ok && matchesHash(token, hash)
+}
+
+// ParticipantFor returns the name of the participant the token was issued
+// to.
+func (s *Session) ParticipantFor(token string) (string, bool) {
+	for name := range s.ParticipantHashes {
+		if s.IsParticipant(name, token) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// ObserverFor returns the name of the observer the token was issued to.
+func (s *Session) ObserverFor(token string) (string, bool) {
+	for name, hash := range s.ObserverHashes {
+		if matchesHash(token, hash) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func matchesHash(token string, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(hash)) == 1
+}
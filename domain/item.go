@@ -0,0 +1,68 @@
+package domain
+
+import "errors"
+
+var ErrInvalidOrder = errors.New("not a valid order")
+
+type Item struct {
+	Title       string
+	Description string
+	Link        string
+	Estimate    string
+}
+
+// CurrentItem returns the backlog item being estimated or nil when there is
+// none.
+func (s *Session) CurrentItem() *Item {
+	if s.Current < 0 || s.Current >= len(s.Items) {
+		return nil
+	}
+	return &s.Items[s.Current]
+}
+
+// NextItem moves the session to the item after the current one.
+func (s *Session) NextItem() (*Item, bool) {
+	if s.Current+1 >= len(s.Items) {
+		return nil, false
+	}
+	s.Current++
+	return &s.Items[s.Current], true
+}
+
+// RemoveItem deletes the nth item. Removing the current one moves the
+// session back to the item before it, so the next one is the item that
+// followed.
+func (s *Session) RemoveItem(n int) {
+	s.Items = append(s.Items[:n], s.Items[n+1:]...)
+
+	if n <= s.Current {
+		s.Current--
+	}
+}
+
+// ReorderItems rearranges the backlog so that the item at order[i] is moved
+// to position i. The current item is kept.
+func (s *Session) ReorderItems(order []int) error {
+	if len(order) != len(s.Items) {
+		return ErrInvalidOrder
+	}
+
+	seen := make([]bool, len(order))
+	items := make([]Item, len(order))
+	current := -1
+	for i, from := range order {
+		if from < 0 || from >= len(s.Items) || seen[from] {
+			return ErrInvalidOrder
+		}
+		seen[from] = true
+
+		items[i] = s.Items[from]
+		if from == s.Current {
+			current = i
+		}
+	}
+
+	s.Items = items
+	s.Current = current
+	return nil
+}
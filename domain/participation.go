@@ -0,0 +1,107 @@
+package domain
+
+// Votes that can be cast besides the choices of the session. They count
+// toward completing the round but not in the statistics.
+const (
+	Abstain = "abstain"
+	Unsure  = "?"
+)
+
+// ValidChoice tells if the choice can be voted on.
+func (s *Session) ValidChoice(choice string) bool {
+	if choice == Abstain || choice == Unsure {
+		return true
+	}
+	for _, c := range s.Choices {
+		if c == choice {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSittingOut tells if the participant sits out the rounds.
+func (s *Session) IsSittingOut(participant string) bool {
+	for _, p := range s.SittingOut {
+		if p == participant {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSittingOut changes if the participant sits out the rounds.
+func (s *Session) SetSittingOut(participant string, sittingOut bool) {
+	res := []string{}
+	for _, p := range s.SittingOut {
+		if p != participant {
+			res = append(res, p)
+		}
+	}
+	if sittingOut {
+		res = append(res, participant)
+	}
+	s.SittingOut = res
+}
+
+// RemoveParticipant takes the participant out of the session with their vote
+// in the current round. In anonymous rounds the vote cannot be told apart from
+// the others, so only the participant is not waited for anymore.
+func (s *Session) RemoveParticipant(participant string) bool {
+	idx := -1
+	for i, p := range s.Participants {
+		if p == participant {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return false
+	}
+
+	s.Participants = append(s.Participants[:idx], s.Participants[idx+1:]...)
+	delete(s.ParticipantHashes, participant)
+	s.SetSittingOut(participant, false)
+
+	if s.Settings.Anonymous {
+		res := []string{}
+		for _, p := range s.Voters {
+			if p != participant {
+				res = append(res, p)
+			}
+		}
+		s.Voters = res
+	} else {
+		delete(s.Votes, participant)
+	}
+
+	return true
+}
+
+// Complete tells if everybody not sitting out has voted in the current round.
+// A round without votes is never complete.
+func (s *Session) Complete() bool {
+	for _, p := range s.Participants {
+		if s.IsSittingOut(p) {
+			continue
+		}
+		if !s.hasVoted(p) {
+			return false
+		}
+	}
+	return len(s.Votes) > 0
+}
+
+func (s *Session) hasVoted(participant string) bool {
+	if s.Settings.Anonymous {
+		for _, p := range s.Voters {
+			if p == participant {
+				return true
+			}
+		}
+		return false
+	}
+
+	_, ok := s.Votes[participant]
+	return ok
+}
@@ -0,0 +1,119 @@
+package domain
+
+import "sort"
+
+type Result struct {
+	Histogram map[string]int
+	Mode      []string
+	Consensus bool
+
+	// Statistics is nil when no vote was cast on a choice counting in them.
+	Statistics *Statistics
+}
+
+type Statistics struct {
+	Average float64
+	Median  float64
+	Min     float64
+	Max     float64
+	Spread  float64
+}
+
+// Result summarizes the votes of the current round.
+func (s *Session) Result() *Result {
+	res := &Result{
+		Histogram: countVotes(s.Choices, s.Votes),
+		Mode:      []string{},
+	}
+
+	values := []float64{}
+	for _, choice := range s.Votes {
+		if c := s.Card(choice); c.Counts {
+			values = append(values, c.Value)
+		}
+	}
+
+	most := 0
+	for _, c := range s.Choices {
+		switch n := res.Histogram[c]; {
+		case n == 0:
+		case n > most:
+			most = n
+			res.Mode = []string{c}
+		case n == most:
+			res.Mode = append(res.Mode, c)
+		}
+	}
+
+	s.SortChoices(res.Mode)
+
+	res.Consensus = consensus(s.Votes)
+
+	if len(values) > 0 {
+		res.Statistics = newStatistics(values)
+	}
+
+	return res
+}
+
+// consensus tells if the votes are all the same, leaving out the abstaining
+// and unsure ones.
+func consensus(votes map[string]string) bool {
+	agreed := ""
+	for _, choice := range votes {
+		if !isEstimate(choice) {
+			continue
+		}
+		if agreed != "" && choice != agreed {
+			return false
+		}
+		agreed = choice
+	}
+	return agreed != ""
+}
+
+// countVotes returns the number of votes per choice, zero for the ones not
+// voted on. The abstaining and unsure votes are left out.
+func countVotes(choices []string, votes map[string]string) map[string]int {
+	res := make(map[string]int, len(choices))
+	for _, c := range choices {
+		if isEstimate(c) {
+			res[c] = 0
+		}
+	}
+	for _, choice := range votes {
+		if isEstimate(choice) {
+			res[choice]++
+		}
+	}
+	return res
+}
+
+// isEstimate tells if the vote is not abstaining or unsure.
+func isEstimate(choice string) bool {
+	return choice != Abstain && choice != Unsure
+}
+
+func newStatistics(values []float64) *Statistics {
+	sort.Float64s(values)
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+
+	median := values[len(values)/2]
+	if len(values)%2 == 0 {
+		median = (values[len(values)/2-1] + median) / 2
+	}
+
+	min, max := values[0], values[len(values)-1]
+
+	return &Statistics{
+		Average: sum / float64(len(values)),
+		Median:  median,
+		Min:     min,
+		Max:     max,
+		Spread:  max - min,
+	}
+}
@@ -0,0 +1,125 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akarasz/pajthy-backend/domain"
+)
+
+func TestResult(t *testing.T) {
+	s := domain.NewSession()
+	s.Choices = []string{"1", "2", "3", "5", "?"}
+
+	// no votes
+	got := s.Result()
+	assert.Exactly(t, map[string]int{"1": 0, "2": 0, "3": 0, "5": 0}, got.Histogram)
+	assert.Empty(t, got.Mode)
+	assert.False(t, got.Consensus)
+	assert.Nil(t, got.Statistics)
+
+	// numeric and non-numeric votes
+	s.Votes = map[string]string{
+		"Alice": "1",
+		"Bob":   "3",
+		"Carol": "3",
+		"Dave":  "5",
+		"Eve":   "?",
+	}
+	got = s.Result()
+	assert.Exactly(t, map[string]int{"1": 1, "2": 0, "3": 2, "5": 1}, got.Histogram)
+	assert.Exactly(t, []string{"3"}, got.Mode)
+	assert.False(t, got.Consensus)
+	assert.Exactly(t, &domain.Statistics{
+		Average: 3,
+		Median:  3,
+		Min:     1,
+		Max:     5,
+		Spread:  4,
+	}, got.Statistics)
+
+	// ties in mode and median of even count
+	s.Votes = map[string]string{
+		"Alice": "1",
+		"Bob":   "2",
+	}
+	got = s.Result()
+	assert.Exactly(t, []string{"1", "2"}, got.Mode)
+	assert.Exactly(t, 1.5, got.Statistics.Median)
+
+	// everybody agrees
+	s.Votes = map[string]string{
+		"Alice": "5",
+		"Bob":   "5",
+	}
+	got = s.Result()
+	assert.True(t, got.Consensus)
+	assert.Exactly(t, 0.0, got.Statistics.Spread)
+
+	// abstaining and unsure votes do not break the consensus
+	s.Votes = map[string]string{
+		"Alice": "2",
+		"Bob":   "2",
+		"Carol": domain.Abstain,
+		"Dave":  domain.Unsure,
+	}
+	got = s.Result()
+	assert.True(t, got.Consensus)
+
+	// but there is none without an estimate
+	s.Votes = map[string]string{
+		"Alice": domain.Abstain,
+		"Bob":   domain.Unsure,
+	}
+	got = s.Result()
+	assert.False(t, got.Consensus)
+}
+
+func TestResult_Deck(t *testing.T) {
+	s := domain.NewSession()
+	s.Deck, _ = domain.Deck("t-shirt")
+	s.Choices = domain.Choices(s.Deck)
+
+	// the values of the cards are used, special cards are left out
+	s.Votes = map[string]string{
+		"Alice": "L",
+		"Bob":   "S",
+		"Carol": "coffee",
+		"Dave":  "S",
+		"Eve":   "L",
+	}
+	got := s.Result()
+	assert.Exactly(t, []string{"S", "L"}, got.Mode)
+	assert.Exactly(t, &domain.Statistics{
+		Average: 3,
+		Median:  3,
+		Min:     2,
+		Max:     4,
+		Spread:  2,
+	}, got.Statistics)
+
+	// abstaining and unsure votes are left out of the mode and the histogram
+	s.Deck, _ = domain.Deck("fibonacci")
+	s.Choices = domain.Choices(s.Deck)
+	s.Votes = map[string]string{
+		"Alice": domain.Unsure,
+		"Bob":   domain.Unsure,
+		"Carol": "5",
+		"Dave":  domain.Abstain,
+		"Eve":   domain.Abstain,
+		"Frank": domain.Abstain,
+	}
+	got = s.Result()
+	assert.Exactly(t, []string{"5"}, got.Mode)
+	assert.Exactly(t, 1, got.Histogram["5"])
+	assert.NotContains(t, got.Histogram, domain.Unsure)
+	assert.NotContains(t, got.Histogram, domain.Abstain)
+	assert.True(t, got.Consensus)
+
+	// choices are sorted by value, special cards last
+	s.Deck, _ = domain.Deck("t-shirt")
+	choices := []string{"?", "XL", "XS", "M"}
+	s.SortChoices(choices)
+	assert.Exactly(t, []string{"XS", "M", "XL", "?"}, choices)
+}
@@ -0,0 +1,112 @@
+package domain
+
+import "time"
+
+type Round struct {
+	Topic        string
+	Choices      []string
+	Participants []string
+	Votes        map[string]string
+	Opened       time.Time
+	Closed       time.Time
+
+	// Counts has the number of votes per choice instead of the votes when
+	// the round was anonymous.
+	Counts map[string]int
+}
+
+// Start opens a new round on the topic after archiving the current one,
+// which is returned. The round is closed after the duration, or after the
+// one of the timer policy if it is zero.
+func (s *Session) Start(now time.Time, topic string, duration time.Duration) (*Round, bool) {
+	archived, ok := s.Archive(now)
+
+	s.Open = true
+	s.ClearVotes()
+	s.Topic = topic
+	s.Opened = now
+
+	if duration == 0 && s.Settings.AutoClose == Timer {
+		duration = s.Settings.TimerDuration()
+	}
+	if duration > 0 {
+		s.Deadline = now.Add(duration)
+	}
+
+	return archived, ok
+}
+
+// Archive finishes the current round before the next one, moving it to the
+// history of the session unless it was done already when it got closed. It
+// returns the round archived now. Nothing happens if no round was started
+// since the last archiving.
+func (s *Session) Archive(now time.Time) (*Round, bool) {
+	if s.Opened.IsZero() {
+		return nil, false
+	}
+
+	var res *Round
+	if s.Closed.IsZero() {
+		res = s.record(now)
+	}
+
+	s.Topic = ""
+	s.Opened = time.Time{}
+	s.Closed = time.Time{}
+	s.Deadline = time.Time{}
+	s.Closing = time.Time{}
+
+	return res, res != nil
+}
+
+// Close ends the current round and moves it to the history of the session,
+// returning it. The round stays the current one until the next is started.
+func (s *Session) Close(now time.Time) (*Round, bool) {
+	s.Open = false
+	s.Closing = time.Time{}
+	if !s.Closed.IsZero() || s.Opened.IsZero() {
+		return nil, false
+	}
+
+	s.Closed = now
+	return s.record(now), true
+}
+
+func (s *Session) record(closed time.Time) *Round {
+	var votes map[string]string
+	if named := s.NamedVotes(); named != nil {
+		votes = make(map[string]string, len(named))
+		for k, v := range named {
+			votes[k] = v
+		}
+	}
+
+	r := Round{
+		Topic:        s.Topic,
+		Choices:      append([]string{}, s.Choices...),
+		Participants: append([]string{}, s.Participants...),
+		Votes:        votes,
+		Counts:       s.AnonymousCounts(),
+		Opened:       s.Opened,
+		Closed:       closed,
+	}
+	s.Rounds = append(s.Rounds, r)
+
+	return &r
+}
+
+// Expired tells if the current round is still open after its deadline or the
+// end of its grace period.
+func (s *Session) Expired(now time.Time) bool {
+	if !s.Open {
+		return false
+	}
+	return (!s.Deadline.IsZero() && !now.Before(s.Deadline)) ||
+		(!s.Closing.IsZero() && !now.Before(s.Closing))
+}
+
+// Revealed tells if the current round is over, so its votes can be shown to
+// everybody.
+func (s *Session) Revealed() bool {
+	return !s.Open && !s.Closed.IsZero()
+}
@@ -0,0 +1,109 @@
+package domain
+
+// Settings are the options of a session the controller can change any time.
+type Settings struct {
+	// HideNames reveals only the result to the voters and the observers when
+	// the round closes, without telling who voted what.
+	HideNames bool
+
+	// Anonymous keeps the votes by a hash derived from the token of the
+	// participants that is not stored anywhere else, so nobody can tell who
+	// voted what, only the number of votes per choice.
+	// It cannot be changed while votes are cast in an open round.
+	Anonymous bool
+
+	// AutoClose is the policy closing the rounds. Quorum is the percentage
+	// of votes needed by the quorum policy, Timer is the duration, like
+	// "5m", of the rounds by the timer policy.
+	AutoClose AutoClose
+	Quorum    int
+	Timer     string
+
+	// Grace is the time, like "10s", given to change the votes before the
+	// round is closed by the policy.
+	Grace string
+
+	// HideChoices shows the controllers only who has voted until the round
+	// is closed.
+	HideChoices bool
+}
+
+// VoteKey returns the key the vote of the participant with the token is kept
+// by.
+func (s *Session) VoteKey(participant string, token string) string {
+	if s.Settings.Anonymous {
+		return HashToken("vote:" + token)
+	}
+	return participant
+}
+
+// CastVote records the vote of the participant with the token, replacing the
+// previous one.
+func (s *Session) CastVote(participant string, token string, choice string) {
+	if s.Settings.Anonymous && !s.hasVoted(participant) {
+		s.Voters = append(s.Voters, participant)
+	}
+	s.Votes[s.VoteKey(participant, token)] = choice
+}
+
+// RetractVote withdraws the vote of the participant with the token.
+func (s *Session) RetractVote(participant string, token string) {
+	delete(s.Votes, s.VoteKey(participant, token))
+
+	res := []string{}
+	for _, p := range s.Voters {
+		if p != participant {
+			res = append(res, p)
+		}
+	}
+	s.Voters = res
+}
+
+// ClearVotes removes the votes of the current round.
+func (s *Session) ClearVotes() {
+	s.Votes = map[string]string{}
+	s.Voters = []string{}
+}
+
+// NamedVotes returns the votes by participant. It is nil when the session is
+// anonymous.
+func (s *Session) NamedVotes() map[string]string {
+	if s.Settings.Anonymous {
+		return nil
+	}
+	return s.Votes
+}
+
+// AnonymousCounts returns the number of votes per choice when the session is
+// anonymous, nil otherwise.
+func (s *Session) AnonymousCounts() map[string]int {
+	if !s.Settings.Anonymous {
+		return nil
+	}
+	return countVotes(s.Choices, s.Votes)
+}
+
+// Voted returns the participants having a vote in the current round.
+func (s *Session) Voted() []string {
+	res := []string{}
+	for _, p := range s.Participants {
+		if s.hasVoted(p) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// ChoicesHidden tells if the controllers see only who has voted.
+func (s *Session) ChoicesHidden() bool {
+	return s.Settings.HideChoices && s.Open
+}
+
+// RevealedVotes returns the votes as the voters and the observers see them
+// after the round is closed. It is nil when the names are hidden.
+func (s *Session) RevealedVotes() map[string]string {
+	if s.Settings.HideNames {
+		return nil
+	}
+	return s.NamedVotes()
+}
@@ -0,0 +1,138 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewaymanagementapi"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewaymanagementapi/types"
+)
+
+// Connections keeps track of the API Gateway websocket connections subscribed
+// to the sessions.
+type Connections interface {
+	Add(sessionID string, r Role, connectionID string) error
+	Remove(connectionID string) error
+	List(sessionID string, r Role) ([]string, error)
+	Sessions() ([]string, error)
+}
+
+// APIGateway pushes the events to the clients connected to an API Gateway
+// websocket API through its @connections management API. It has no long
+// living state, the subscribers are kept in the connections.
+type APIGateway struct {
+	client      *apigatewaymanagementapi.Client
+	connections Connections
+}
+
+// NewAPIGateway creates the event for the websocket API served on endpoint,
+// like https://{api-id}.execute-api.{region}.amazonaws.com/{stage}.
+func NewAPIGateway(c *aws.Config, endpoint string, connections Connections) *APIGateway {
+	client := apigatewaymanagementapi.NewFromConfig(*c,
+		apigatewaymanagementapi.WithEndpointResolver(
+			apigatewaymanagementapi.EndpointResolverFromURL(endpoint)))
+
+	return &APIGateway{
+		client:      client,
+		connections: connections,
+	}
+}
+
+func (e *APIGateway) Emit(sessionID string, r Role, t Type, body interface{}) {
+	ids, err := e.connections.List(sessionID, r)
+	if err != nil {
+		log.Printf("listing connections of %q: %v", sessionID, err)
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(NewPayload(t, body))
+	if err != nil {
+		log.Printf("api gateway message encoding: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		_, err := e.client.PostToConnection(context.TODO(), &apigatewaymanagementapi.PostToConnectionInput{
+			ConnectionId: aws.String(id),
+			Data:         data,
+		})
+		if err == nil {
+			continue
+		}
+
+		var gone *types.GoneException
+		if errors.As(err, &gone) {
+			if err := e.connections.Remove(id); err != nil {
+				log.Printf("removing connection %q: %v", id, err)
+			}
+			continue
+		}
+
+		log.Printf("posting to connection %q: %v", id, err)
+	}
+}
+
+// Subscribe registers the connection id given as ws. The returned channel is
+// always nil as the events are pushed by API Gateway.
+func (e *APIGateway) Subscribe(sessionID string, r Role, ws interface{}) (chan *Payload, error) {
+	id, ok := ws.(string)
+	if !ok {
+		return nil, fmt.Errorf("connection id expected, got %T", ws)
+	}
+
+	log.Printf("subscribe %q", sessionID)
+	return nil, e.connections.Add(sessionID, r, id)
+}
+
+// Unsubscribe removes the connection id given as ws. The session is not
+// needed as API Gateway does not tell it when disconnecting.
+func (e *APIGateway) Unsubscribe(sessionID string, ws interface{}) error {
+	id, ok := ws.(string)
+	if !ok {
+		return fmt.Errorf("connection id expected, got %T", ws)
+	}
+
+	log.Printf("unsubscribe %q", id)
+	return e.connections.Remove(id)
+}
+
+func (e *APIGateway) Sessions() []string {
+	res, err := e.connections.Sessions()
+	if err != nil {
+		log.Printf("listing sessions: %v", err)
+		return nil
+	}
+	return res
+}
+
+func (e *APIGateway) Close(sessionID string) {
+	log.Printf("close %q", sessionID)
+	for _, r := range roles {
+		ids, err := e.connections.List(sessionID, r)
+		if err != nil {
+			log.Printf("listing connections of %q: %v", sessionID, err)
+			continue
+		}
+
+		for _, id := range ids {
+			_, err := e.client.DeleteConnection(context.TODO(), &apigatewaymanagementapi.DeleteConnectionInput{
+				ConnectionId: aws.String(id),
+			})
+			var gone *types.GoneException
+			if err != nil && !errors.As(err, &gone) {
+				log.Printf("deleting connection %q: %v", id, err)
+			}
+
+			if err := e.connections.Remove(id); err != nil {
+				log.Printf("removing connection %q: %v", id, err)
+			}
+		}
+	}
+}
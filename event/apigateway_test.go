@@ -0,0 +1,217 @@
+package event_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/pajthy-backend/event"
+)
+
+func TestAPIGateway_Emit(t *testing.T) {
+	api := newManagementAPI()
+	defer api.Close()
+	e := event.NewAPIGateway(awsConfig(), api.URL, newConnections())
+
+	_, err := e.Subscribe("id", event.Voter, "alice")
+	require.NoError(t, err)
+	_, err = e.Subscribe("id", event.Voter, "bob")
+	require.NoError(t, err)
+	_, err = e.Subscribe("id", event.Controller, "controller")
+	require.NoError(t, err)
+	_, err = e.Subscribe("other", event.Voter, "carol")
+	require.NoError(t, err)
+
+	e.Emit("id", event.Voter, event.Enabled, true)
+
+	assert.ElementsMatch(t, []string{"alice", "bob"}, api.postedTo())
+	for _, body := range api.posted {
+		assert.JSONEq(t, `{"Kind":"enabled","Data":true}`, body)
+	}
+}
+
+func TestAPIGateway_EmitRemovesGoneConnections(t *testing.T) {
+	api := newManagementAPI()
+	defer api.Close()
+	c := newConnections()
+	e := event.NewAPIGateway(awsConfig(), api.URL, c)
+
+	_, err := e.Subscribe("id", event.Voter, "alice")
+	require.NoError(t, err)
+	_, err = e.Subscribe("id", event.Voter, "bob")
+	require.NoError(t, err)
+	api.gone["bob"] = true
+
+	e.Emit("id", event.Voter, event.Enabled, true)
+
+	assert.Equal(t, []string{"alice"}, api.postedTo())
+	ids, err := c.List("id", event.Voter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, ids)
+}
+
+func TestAPIGateway_Unsubscribe(t *testing.T) {
+	api := newManagementAPI()
+	defer api.Close()
+	e := event.NewAPIGateway(awsConfig(), api.URL, newConnections())
+
+	_, err := e.Subscribe("id", event.Voter, "alice")
+	require.NoError(t, err)
+	require.NoError(t, e.Unsubscribe("", "alice"))
+
+	e.Emit("id", event.Voter, event.Enabled, true)
+
+	assert.Empty(t, api.postedTo())
+	assert.Empty(t, e.Sessions())
+
+	_, err = e.Subscribe("id", event.Voter, struct{}{})
+	assert.Error(t, err)
+}
+
+func TestAPIGateway_Close(t *testing.T) {
+	api := newManagementAPI()
+	defer api.Close()
+	e := event.NewAPIGateway(awsConfig(), api.URL, newConnections())
+
+	_, err := e.Subscribe("id", event.Voter, "alice")
+	require.NoError(t, err)
+	_, err = e.Subscribe("id", event.Controller, "controller")
+	require.NoError(t, err)
+	_, err = e.Subscribe("other", event.Voter, "carol")
+	require.NoError(t, err)
+
+	e.Close("id")
+
+	assert.ElementsMatch(t, []string{"alice", "controller"}, api.deleted)
+	assert.Equal(t, []string{"other"}, e.Sessions())
+}
+
+func awsConfig() *aws.Config {
+	return &aws.Config{
+		Region: "eu-central-1",
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "key", SecretAccessKey: "secret"}, nil
+		}),
+	}
+}
+
+// managementAPI stands in for the @connections API of API Gateway.
+type managementAPI struct {
+	*httptest.Server
+	sync.Mutex
+
+	gone    map[string]bool
+	posted  map[string]string
+	deleted []string
+}
+
+func newManagementAPI() *managementAPI {
+	res := &managementAPI{
+		gone:   map[string]bool{},
+		posted: map[string]string{},
+	}
+	res.Server = httptest.NewServer(http.HandlerFunc(res.serve))
+	return res
+}
+
+func (m *managementAPI) serve(w http.ResponseWriter, r *http.Request) {
+	m.Lock()
+	defer m.Unlock()
+
+	id := strings.TrimPrefix(r.URL.Path, "/@connections/")
+	if m.gone[id] {
+		w.Header().Set("X-Amzn-ErrorType", "GoneException")
+		w.WriteHeader(http.StatusGone)
+		w.Write([]byte(`{"message":"gone"}`))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		body, _ := ioutil.ReadAll(r.Body)
+		m.posted[id] = string(body)
+	case http.MethodDelete:
+		m.deleted = append(m.deleted, id)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *managementAPI) postedTo() []string {
+	m.Lock()
+	defer m.Unlock()
+
+	res := []string{}
+	for id := range m.posted {
+		res = append(res, id)
+	}
+	sort.Strings(res)
+	return res
+}
+
+type connection struct {
+	sessionID string
+	role      event.Role
+}
+
+type connections struct {
+	sync.Mutex
+	byID map[string]connection
+}
+
+func newConnections() *connections {
+	return &connections{byID: map[string]connection{}}
+}
+
+func (c *connections) Add(sessionID string, r event.Role, connectionID string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	c.byID[connectionID] = connection{sessionID: sessionID, role: r}
+	return nil
+}
+
+func (c *connections) Remove(connectionID string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.byID, connectionID)
+	return nil
+}
+
+func (c *connections) List(sessionID string, r event.Role) ([]string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	res := []string{}
+	for id, conn := range c.byID {
+		if conn.sessionID == sessionID && conn.role == r {
+			res = append(res, id)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func (c *connections) Sessions() ([]string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	seen := map[string]bool{}
+	res := []string{}
+	for _, conn := range c.byID {
+		if !seen[conn.sessionID] {
+			seen[conn.sessionID] = true
+			res = append(res, conn.sessionID)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,159 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// connectionTTL is the longest time API Gateway keeps a websocket connection
+// open. Connections missing their $disconnect are reaped after it.
+const connectionTTL = 2 * time.Hour
+
+// DefaultConnectionsIndex is the name of the SessionID index unless
+// configured otherwise.
+const DefaultConnectionsIndex = "SessionID-index"
+
+// DynamoDBConnections keeps the connections in a table keyed by ConnectionID
+// with a global secondary index on SessionID.
+type DynamoDBConnections struct {
+	client *dynamodb.Client
+	table  *string
+	index  *string
+}
+
+func NewDynamoDBConnections(c *aws.Config, table string, index string) *DynamoDBConnections {
+	return &DynamoDBConnections{
+		client: dynamodb.NewFromConfig(*c),
+		table:  aws.String(table),
+		index:  aws.String(index),
+	}
+}
+
+type dynamoConnectionKey struct {
+	ConnectionID string
+}
+
+type dynamoConnection struct {
+	dynamoConnectionKey
+	SessionID string
+	Role      Role
+
+	// TTL is the epoch second after which DynamoDB can reap the item.
+	TTL int64 `dynamodbav:"ttl"`
+}
+
+func (d *DynamoDBConnections) Add(sessionID string, r Role, connectionID string) error {
+	item, err := attributevalue.MarshalMap(&dynamoConnection{
+		dynamoConnectionKey: dynamoConnectionKey{ConnectionID: connectionID},
+		SessionID:           sessionID,
+		Role:                r,
+		TTL:                 time.Now().Add(connectionTTL).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = d.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName: d.table,
+		Item:      item,
+	})
+	return err
+}
+
+func (d *DynamoDBConnections) Remove(connectionID string) error {
+	key, err := attributevalue.MarshalMap(&dynamoConnectionKey{ConnectionID: connectionID})
+	if err != nil {
+		return err
+	}
+
+	_, err = d.client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: d.table,
+		Key:       key,
+	})
+	return err
+}
+
+func (d *DynamoDBConnections) List(sessionID string, r Role) ([]string, error) {
+	expr, err := expression.NewBuilder().
+		WithKeyCondition(expression.Key("SessionID").Equal(expression.Value(sessionID))).
+		WithFilter(expression.Name("Role").Equal(expression.Value(r))).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &dynamodb.QueryInput{
+		TableName:                 d.table,
+		IndexName:                 d.index,
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	var res []string
+	for {
+		page, err := d.client.Query(context.TODO(), req)
+		if err != nil {
+			return nil, err
+		}
+
+		var items []dynamoConnection
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			res = append(res, item.ConnectionID)
+		}
+
+		if len(page.LastEvaluatedKey) == 0 {
+			return res, nil
+		}
+		req.ExclusiveStartKey = page.LastEvaluatedKey
+	}
+}
+
+func (d *DynamoDBConnections) Sessions() ([]string, error) {
+	expr, err := expression.NewBuilder().
+		WithProjection(expression.NamesList(expression.Name("SessionID"))).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &dynamodb.ScanInput{
+		TableName:                d.table,
+		ProjectionExpression:     expr.Projection(),
+		ExpressionAttributeNames: expr.Names(),
+	}
+
+	seen := map[string]bool{}
+	res := []string{}
+	for {
+		page, err := d.client.Scan(context.TODO(), req)
+		if err != nil {
+			return nil, err
+		}
+
+		var items []dynamoConnection
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if !seen[item.SessionID] {
+				seen[item.SessionID] = true
+				res = append(res, item.SessionID)
+			}
+		}
+
+		if len(page.LastEvaluatedKey) == 0 {
+			return res, nil
+		}
+		req.ExclusiveStartKey = page.LastEvaluatedKey
+	}
+}
@@ -0,0 +1,122 @@
+package event_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/akarasz/pajthy-backend/event"
+)
+
+func TestDynamoDBConnections(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping event/dynamodb test")
+	}
+
+	ctx := context.Background()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "amazon/dynamodb-local:latest",
+			ExposedPorts: []string{"8000/tcp"},
+			WaitingFor:   wait.ForListeningPort("8000/tcp"),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	defer container.Terminate(ctx)
+
+	ip, err := container.Host(ctx)
+	require.NoError(t, err)
+	port, err := container.MappedPort(ctx, "8000")
+	require.NoError(t, err)
+
+	customResolver := aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
+		if service == dynamodb.ServiceID {
+			return aws.Endpoint{
+				PartitionID:   "aws",
+				URL:           fmt.Sprintf("http://%s:%s", ip, port.Port()),
+				SigningRegion: region,
+			}, nil
+		}
+		return aws.Endpoint{}, fmt.Errorf("unknown endpoint requested")
+	})
+
+	c, err := config.LoadDefaultConfig(ctx, config.WithEndpointResolver(customResolver))
+	require.NoError(t, err)
+
+	client := dynamodb.NewFromConfig(c)
+
+	throughput := &types.ProvisionedThroughput{
+		ReadCapacityUnits:  aws.Int64(5),
+		WriteCapacityUnits: aws.Int64(5),
+	}
+	_, err = client.CreateTable(context.TODO(), &dynamodb.CreateTableInput{
+		TableName: aws.String("testConnections"),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("ConnectionID"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("SessionID"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("ConnectionID"),
+				KeyType:       types.KeyTypeHash,
+			},
+		},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
+			{
+				IndexName: aws.String("SessionID-index"),
+				KeySchema: []types.KeySchemaElement{
+					{
+						AttributeName: aws.String("SessionID"),
+						KeyType:       types.KeyTypeHash,
+					},
+				},
+				Projection: &types.Projection{
+					ProjectionType: types.ProjectionTypeAll,
+				},
+				ProvisionedThroughput: throughput,
+			},
+		},
+		ProvisionedThroughput: throughput,
+	})
+	require.NoError(t, err)
+
+	conns := event.NewDynamoDBConnections(&c, "testConnections", "SessionID-index")
+
+	require.NoError(t, conns.Add("id", event.Voter, "alice"))
+	require.NoError(t, conns.Add("id", event.Voter, "bob"))
+	require.NoError(t, conns.Add("id", event.Controller, "controller"))
+	require.NoError(t, conns.Add("other", event.Voter, "carol"))
+
+	voters, err := conns.List("id", event.Voter)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alice", "bob"}, voters)
+
+	controllers, err := conns.List("id", event.Controller)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"controller"}, controllers)
+
+	sessions, err := conns.Sessions()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"id", "other"}, sessions)
+
+	require.NoError(t, conns.Remove("bob"))
+	voters, err = conns.List("id", event.Voter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, voters)
+}
@@ -1,18 +1,16 @@
 package event
 
-import (
-	"errors"
-	"log"
-	"sync"
-)
-
 type Role int
 
 const (
 	Voter Role = iota
 	Controller
+	// Observer watches the session without voting.
+	Observer
 )
 
+var roles = []Role{Voter, Controller, Observer}
+
 type Type string
 
 const (
@@ -22,9 +20,21 @@ const (
 	ParticipantsChange = Type("participants-change")
 	Vote               = Type("vote")
 	Done               = Type("done")
+	RoundArchived      = Type("round-archived")
+	CurrentItem        = Type("current-item")
+	ChoicesChange      = Type("choices-change")
+	Expired            = Type("expired")
+	Snapshot           = Type("snapshot")
+	Presence           = Type("presence")
+	// Resync tells the client that it missed payloads no longer kept, so it
+	// has to load the whole state again.
+	Resync = Type("resync")
 )
 
 type Payload struct {
+	// ID numbers the payloads of a session, zero when the event does not
+	// keep track of them.
+	ID   uint64 `json:",omitempty"`
 	Kind Type
 	Data interface{}
 }
@@ -36,110 +46,91 @@ func NewPayload(kind Type, data interface{}) *Payload {
 	}
 }
 
-type Event struct {
-	sync.RWMutex
-	sessions map[string]*session
-}
-
-func New() *Event {
-	return &Event{
-		sessions: map[string]*session{},
-	}
-}
+type Event interface {
+	Emit(sessionID string, r Role, t Type, body interface{})
+	Subscribe(sessionID string, r Role, ws interface{}) (chan *Payload, error)
+	Unsubscribe(sessionID string, ws interface{}) error
 
-type session struct {
-	sync.RWMutex
-	voters      map[interface{}]chan *Payload
-	controllers map[interface{}]chan *Payload
+	// Sessions returns the ids of the sessions having subscribers or other
+	// state kept by the event.
+	Sessions() []string
+	// Close unsubscribes everybody from the session and forgets about it.
+	Close(sessionID string)
 }
 
-func newSession() *session {
-	return &session{
-		voters:      map[interface{}]chan *Payload{},
-		controllers: map[interface{}]chan *Payload{},
-	}
+// Replayer is implemented by the events keeping the recent payloads of the
+// sessions.
+type Replayer interface {
+	// Replay returns the payloads emitted to the role after the one with the
+	// given id. It is false when some of them are not kept anymore.
+	Replay(sessionID string, r Role, after uint64) ([]*Payload, bool)
 }
 
-func (e *Event) Emit(sessionID string, r Role, t Type, body interface{}) {
-	e.RLock()
-	s, exists := e.sessions[sessionID]
-	e.RUnlock()
-
-	if !exists {
-		return
-	}
+// Policy decides what happens with a subscriber whose buffer is full.
+type Policy int
 
-	s.RLock()
-	switch r {
-	case Voter:
-		for _, c := range s.voters {
-			c <- NewPayload(t, body)
-		}
-	case Controller:
-		for _, c := range s.controllers {
-			c <- NewPayload(t, body)
-		}
-	}
-	s.RUnlock()
-}
+const (
+	// Disconnect unsubscribes the slow subscriber, closing its channel.
+	Disconnect Policy = iota
+	// Drop discards the event for the slow subscriber only.
+	Drop
+)
 
-func (e *Event) Subscribe(sessionID string, r Role, ws interface{}) (chan *Payload, error) {
-	log.Printf("subscribe %q", sessionID)
-	c := make(chan *Payload)
+const (
+	defaultBufferSize = 16
+	defaultReplaySize = 64
+)
 
-	var s *session
+type Option func(*options)
 
-	e.RLock()
-	s, exists := e.sessions[sessionID]
-	e.RUnlock()
-	if !exists {
-		s = newSession()
+type options struct {
+	bufferSize int
+	policy     Policy
+	replaySize int
+}
 
-		e.Lock()
-		e.sessions[sessionID] = s
-		e.Unlock()
+// WithBufferSize sets how many events are queued for a subscriber before it
+// counts as slow.
+func WithBufferSize(n int) Option {
+	return func(o *options) {
+		o.bufferSize = n
 	}
+}
 
-	s.Lock()
-	switch r {
-	case Voter:
-		s.voters[ws] = c
-	case Controller:
-		s.controllers[ws] = c
+// WithReplaySize sets how many payloads of a session are kept for the
+// subscribers reconnecting.
+func WithReplaySize(n int) Option {
+	return func(o *options) {
+		o.replaySize = n
 	}
-	s.Unlock()
-
-	return c, nil
 }
 
-func (e *Event) Unsubscribe(sessionID string, ws interface{}) error {
-	log.Printf("unsubscribe %q", sessionID)
-	e.RLock()
-	s, exists := e.sessions[sessionID]
-	e.RUnlock()
-	if !exists {
-		return errors.New("no session found")
+// WithPolicy sets how slow subscribers are treated.
+func WithPolicy(p Policy) Option {
+	return func(o *options) {
+		o.policy = p
 	}
+}
 
-	s.Lock()
-	if c, ok := s.voters[ws]; ok {
-		close(c)
-		delete(s.voters, ws)
+func newOptions(opts []Option) options {
+	res := options{
+		bufferSize: defaultBufferSize,
+		policy:     Disconnect,
+		replaySize: defaultReplaySize,
 	}
-
-	if c, ok := s.controllers[ws]; ok {
-		close(c)
-		delete(s.controllers, ws)
+	for _, o := range opts {
+		o(&res)
 	}
-	s.Unlock()
+	return res
+}
 
-	s.RLock()
-	e.Lock()
-	if len(s.voters)+len(s.controllers) == 0 {
-		delete(e.sessions, sessionID)
-	}
-	e.Unlock()
-	s.RUnlock()
+// Stats counts the events that could not be delivered.
+type Stats struct {
+	Dropped      uint64
+	Disconnected uint64
+}
 
-	return nil
+// Reporter is implemented by the events keeping delivery statistics.
+type Reporter interface {
+	Stats() Stats
 }
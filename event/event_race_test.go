@@ -4,13 +4,15 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/akarasz/pajthy-backend/event"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestParallelSubscribeThenEmitsThenUnsubscribe(t *testing.T) {
-	e := event.New()
+	e := event.NewInMemory()
 	wg := &sync.WaitGroup{}
 
 	for id := 0; id < 3; id++ {
@@ -21,8 +23,7 @@ func TestParallelSubscribeThenEmitsThenUnsubscribe(t *testing.T) {
 				require.NoError(t, err)
 
 				go func(c chan *event.Payload) {
-					for {
-						<-c
+					for range c {
 					}
 				}(c)
 
@@ -47,6 +48,88 @@ func TestParallelSubscribeThenEmitsThenUnsubscribe(t *testing.T) {
 	wg.Wait()
 }
 
+func TestParallelEmitsWithStalledSubscriber_Disconnect(t *testing.T) {
+	e := event.NewInMemory(event.WithBufferSize(4), event.WithPolicy(event.Disconnect))
+
+	stalled, err := e.Subscribe("id", event.Voter, "stalled")
+	require.NoError(t, err)
+	draining := subscribeDraining(t, e, "id", 3)
+
+	emitInParallel(t, e, "id", 50)
+
+	received := 0
+	for range stalled {
+		received++
+	}
+	assert.Equal(t, 4, received)
+
+	e.Close("id")
+	draining.Wait()
+	stats := e.Stats()
+	assert.GreaterOrEqual(t, stats.Disconnected, uint64(1))
+	assert.GreaterOrEqual(t, stats.Dropped, stats.Disconnected)
+}
+
+func TestParallelEmitsWithStalledSubscriber_Drop(t *testing.T) {
+	e := event.NewInMemory(event.WithBufferSize(4), event.WithPolicy(event.Drop))
+
+	stalled, err := e.Subscribe("id", event.Voter, "stalled")
+	require.NoError(t, err)
+	draining := subscribeDraining(t, e, "id", 3)
+
+	emitInParallel(t, e, "id", 50)
+
+	assert.Len(t, stalled, 4)
+	stats := e.Stats()
+	assert.GreaterOrEqual(t, stats.Dropped, uint64(46))
+	assert.Zero(t, stats.Disconnected)
+
+	e.Close("id")
+	draining.Wait()
+}
+
+// subscribeDraining subscribes n voters consuming their events until the
+// channel is closed.
+func subscribeDraining(t *testing.T, e event.Event, id string, n int) *sync.WaitGroup {
+	wg := &sync.WaitGroup{}
+	for ws := 0; ws < n; ws++ {
+		c, err := e.Subscribe(id, event.Voter, wsFor(ws))
+		require.NoError(t, err)
+
+		wg.Add(1)
+		go func(c chan *event.Payload) {
+			for range c {
+			}
+			wg.Done()
+		}(c)
+	}
+	return wg
+}
+
+// emitInParallel fails the test if emitting is blocked by the subscribers.
+func emitInParallel(t *testing.T, e event.Event, id string, n int) {
+	wg := &sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			e.Emit(id, event.Voter, event.Enabled, nil)
+			wg.Done()
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("emit is blocked")
+	}
+}
+
 func idFor(i int) string {
 	return fmt.Sprintf("id%d", i)
 }
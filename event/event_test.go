@@ -5,13 +5,22 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
 
 	"github.com/akarasz/pajthy-backend/domain"
 	"github.com/akarasz/pajthy-backend/event"
 )
 
-func TestSubscribe(t *testing.T) {
-	e := event.New()
+type Suite struct {
+	suite.Suite
+
+	// New returns a fresh subject for every test.
+	New func() event.Event
+}
+
+func (s *Suite) TestSubscribe() {
+	t := s.T()
+	e := s.New()
 
 	// subscribing returns a channel where events can be emitted
 	c, err := e.Subscribe("subscribeID", event.Voter, "wsID")
@@ -22,8 +31,9 @@ func TestSubscribe(t *testing.T) {
 	assert.NotNil(t, <-receivePayload(c), "no event received")
 }
 
-func TestUnsubscribe(t *testing.T) {
-	e := event.New()
+func (s *Suite) TestUnsubscribe() {
+	t := s.T()
+	e := s.New()
 
 	// after unsubscribe emitted events are not showing on channel
 	c := mustSubscribe(t, e, "UnsubscribeID", event.Voter, "wsID")
@@ -38,8 +48,33 @@ func TestUnsubscribe(t *testing.T) {
 	assert.Error(t, err)
 }
 
-func TestEmit_SendingTo(t *testing.T) {
-	e := event.New()
+func (s *Suite) TestClose() {
+	t := s.T()
+	e := s.New()
+
+	alice := mustSubscribe(t, e, "closeID", event.Voter, "alice")
+	bob := mustSubscribe(t, e, "closeID", event.Controller, "bob")
+	mustSubscribe(t, e, "otherID", event.Voter, "carol")
+
+	assert.ElementsMatch(t, []string{"closeID", "otherID"}, e.Sessions())
+
+	// closing a session closes the channels of all subscribers
+	e.Close("closeID")
+
+	_, ok := <-alice
+	assert.False(t, ok)
+	_, ok = <-bob
+	assert.False(t, ok)
+
+	assert.Exactly(t, []string{"otherID"}, e.Sessions())
+
+	// unsubscribing after close returns an error
+	assert.Error(t, e.Unsubscribe("closeID", "alice"))
+}
+
+func (s *Suite) TestEmit_SendingTo() {
+	t := s.T()
+	e := s.New()
 
 	// emitting an event will send a payload to all session subscription for role
 	alice := mustSubscribe(t, e, "a", event.Voter, "alice")
@@ -65,8 +100,9 @@ func TestEmit_SendingTo(t *testing.T) {
 	assert.Nil(t, <-cDave)
 }
 
-func TestEmit_Payload(t *testing.T) {
-	e := event.New()
+func (s *Suite) TestEmit_Payload() {
+	t := s.T()
+	e := s.New()
 
 	c := mustSubscribe(t, e, "emitPayloadID", event.Voter, "wsID")
 	want := event.NewPayload(event.Vote, &domain.Vote{
@@ -77,11 +113,93 @@ func TestEmit_Payload(t *testing.T) {
 	go e.Emit("emitPayloadID", event.Voter, want.Kind, want.Data)
 
 	if got := <-receivePayload(c); assert.NotNil(t, got) {
-		assert.Exactly(t, got, want)
+		assert.Exactly(t, want.Kind, got.Kind)
+		assert.Exactly(t, want.Data, got.Data)
+	}
+}
+
+func (s *Suite) TestReplay() {
+	t := s.T()
+	e := s.New()
+
+	replayer, ok := e.(event.Replayer)
+	if !ok {
+		t.Skip("not a replayer")
+	}
+
+	c := mustSubscribe(t, e, "replayID", event.Voter, "wsID")
+
+	// emitted payloads are numbered
+	go e.Emit("replayID", event.Voter, event.Enabled, nil)
+	first := <-receivePayload(c)
+	if assert.NotNil(t, first) {
+		assert.NotZero(t, first.ID)
+	}
+	go e.Emit("replayID", event.Controller, event.Vote, nil)
+	go e.Emit("replayID", event.Voter, event.Disabled, nil)
+	second := <-receivePayload(c)
+	if assert.NotNil(t, second) {
+		assert.Greater(t, second.ID, first.ID)
+	}
+
+	// the ones of the role after the given id are replayed
+	got, complete := replayer.Replay("replayID", event.Voter, first.ID)
+	assert.True(t, complete)
+	if assert.Len(t, got, 1) {
+		assert.Exactly(t, second.ID, got[0].ID)
+		assert.Exactly(t, event.Disabled, got[0].Kind)
+	}
+
+	got, complete = replayer.Replay("replayID", event.Voter, second.ID)
+	assert.True(t, complete)
+	assert.Empty(t, got)
+
+	// an id the session never reached needs a resync
+	got, complete = replayer.Replay("replayID", event.Voter, second.ID+10)
+	assert.False(t, complete)
+	assert.Empty(t, got)
+}
+
+func (s *Suite) TestPresence() {
+	t := s.T()
+	e := s.New()
+
+	tracker, ok := e.(event.Tracker)
+	if !ok {
+		t.Skip("not a tracker")
+	}
+
+	controller := mustSubscribe(t, e, "presenceID", event.Controller, "controller")
+	mustSubscribe(t, e, "presenceID", event.Voter, "phone")
+	mustSubscribe(t, e, "presenceID", event.Voter, "laptop")
+
+	assertPresence := func(want event.PresenceState) {
+		if got := <-receivePayload(controller); assert.NotNil(t, got) {
+			assert.Exactly(t, event.Presence, got.Kind)
+			assert.Exactly(t, &event.PresenceData{Participant: "Alice", State: want}, got.Data)
+		}
 	}
+
+	// online with the first connection
+	tracker.Track("presenceID", "Alice", "phone")
+	assertPresence(event.Online)
+	tracker.Track("presenceID", "Alice", "laptop")
+	assert.Exactly(t, map[string]event.PresenceState{"Alice": event.Online}, tracker.Presence("presenceID"))
+
+	// idle when every connection is
+	tracker.SetIdle("presenceID", "phone", true)
+	tracker.SetIdle("presenceID", "laptop", true)
+	assertPresence(event.Idle)
+	assert.Exactly(t, map[string]event.PresenceState{"Alice": event.Idle}, tracker.Presence("presenceID"))
+
+	// offline with the last one gone
+	assert.NoError(t, e.Unsubscribe("presenceID", "phone"))
+	assert.NoError(t, e.Unsubscribe("presenceID", "laptop"))
+	assertPresence(event.Offline)
+	assert.Empty(t, tracker.Presence("presenceID"))
 }
 
-func mustSubscribe(t *testing.T, e *event.Event, sessionID string, r event.Role, ws interface{}) chan *event.Payload {
+func mustSubscribe(t *testing.T, e event.Event, sessionID string, r event.Role, ws interface{}) chan *event.Payload {
 	res, err := e.Subscribe(sessionID, r, ws)
 	assert.NoError(t, err)
 	return res
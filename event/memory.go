@@ -0,0 +1,349 @@
+package event
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// InMemory delivers the events to the subscribers without ever blocking the
+// emitter. Every subscriber has a bounded buffer and the ones not keeping up
+// are handled by the configured policy.
+type InMemory struct {
+	sync.RWMutex
+	sessions  map[string]*session
+	histories map[string]*history
+	opts      options
+
+	dropped      uint64
+	disconnected uint64
+
+	// onPresence replaces emitting the presence changes when set.
+	onPresence func(sessionID string, d *PresenceData)
+}
+
+func NewInMemory(opts ...Option) *InMemory {
+	return &InMemory{
+		sessions:  map[string]*session{},
+		histories: map[string]*history{},
+		opts:      newOptions(opts),
+	}
+}
+
+type session struct {
+	sync.RWMutex
+	voters      map[interface{}]chan *Payload
+	controllers map[interface{}]chan *Payload
+	observers   map[interface{}]chan *Payload
+	connections map[interface{}]*connection
+}
+
+func newSession() *session {
+	return &session{
+		voters:      map[interface{}]chan *Payload{},
+		controllers: map[interface{}]chan *Payload{},
+		observers:   map[interface{}]chan *Payload{},
+		connections: map[interface{}]*connection{},
+	}
+}
+
+func (s *session) subscribers(r Role) map[interface{}]chan *Payload {
+	switch r {
+	case Controller:
+		return s.controllers
+	case Observer:
+		return s.observers
+	default:
+		return s.voters
+	}
+}
+
+// history keeps the recent payloads of a session for the subscribers
+// reconnecting. It outlives the subscribers and goes away with Close.
+type history struct {
+	sync.Mutex
+	last    uint64
+	entries []historyEntry
+	next    int
+}
+
+type historyEntry struct {
+	role    Role
+	payload *Payload
+}
+
+func newHistory(size int) *history {
+	return &history{
+		entries: make([]historyEntry, 0, size),
+	}
+}
+
+// record numbers the payload unless it is numbered already and keeps it,
+// overwriting the oldest one when the history is full.
+func (h *history) record(r Role, p *Payload) {
+	if p.ID == 0 {
+		h.last++
+		p.ID = h.last
+	} else if p.ID > h.last {
+		h.last = p.ID
+	}
+
+	if cap(h.entries) == 0 {
+		return
+	}
+	if len(h.entries) < cap(h.entries) {
+		h.entries = append(h.entries, historyEntry{role: r, payload: p})
+		return
+	}
+	h.entries[h.next] = historyEntry{role: r, payload: p}
+	h.next = (h.next + 1) % len(h.entries)
+}
+
+func (h *history) since(r Role, after uint64) ([]*Payload, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	res := []*Payload{}
+	complete := after == h.last
+	for i := range h.entries {
+		entry := h.entries[(h.next+i)%len(h.entries)]
+		if entry.payload.ID == after+1 {
+			complete = true
+		}
+		if entry.payload.ID > after && entry.role == r {
+			res = append(res, entry.payload)
+		}
+	}
+	return res, complete
+}
+
+func (e *InMemory) history(sessionID string) *history {
+	e.RLock()
+	h, exists := e.histories[sessionID]
+	e.RUnlock()
+	if exists {
+		return h
+	}
+
+	e.Lock()
+	defer e.Unlock()
+	if h, exists = e.histories[sessionID]; !exists {
+		h = newHistory(e.opts.replaySize)
+		e.histories[sessionID] = h
+	}
+	return h
+}
+
+func (e *InMemory) Emit(sessionID string, r Role, t Type, body interface{}) {
+	e.emit(sessionID, r, NewPayload(t, body))
+}
+
+func (e *InMemory) emit(sessionID string, r Role, p *Payload) {
+	h := e.history(sessionID)
+
+	// numbering and sending happens together, so the subscribers get the
+	// payloads in order
+	h.Lock()
+	h.record(r, p)
+
+	e.RLock()
+	s, exists := e.sessions[sessionID]
+	e.RUnlock()
+
+	if !exists {
+		h.Unlock()
+		return
+	}
+
+	var slow []interface{}
+
+	s.RLock()
+	for ws, c := range s.subscribers(r) {
+		select {
+		case c <- p:
+		default:
+			atomic.AddUint64(&e.dropped, 1)
+			if e.opts.policy == Disconnect {
+				slow = append(slow, ws)
+			}
+		}
+	}
+	s.RUnlock()
+	h.Unlock()
+
+	for _, ws := range slow {
+		log.Printf("disconnect slow subscriber of %q", sessionID)
+		if e.unsubscribe(sessionID, ws) {
+			atomic.AddUint64(&e.disconnected, 1)
+		}
+	}
+}
+
+func (e *InMemory) Subscribe(sessionID string, r Role, ws interface{}) (chan *Payload, error) {
+	log.Printf("subscribe %q", sessionID)
+	c := make(chan *Payload, e.opts.bufferSize)
+
+	var s *session
+
+	e.RLock()
+	s, exists := e.sessions[sessionID]
+	e.RUnlock()
+	if !exists {
+		s = newSession()
+
+		e.Lock()
+		e.sessions[sessionID] = s
+		e.Unlock()
+	}
+
+	s.Lock()
+	switch r {
+	case Voter:
+		s.voters[ws] = c
+	case Controller:
+		s.controllers[ws] = c
+	case Observer:
+		s.observers[ws] = c
+	}
+	s.Unlock()
+
+	return c, nil
+}
+
+func (e *InMemory) Unsubscribe(sessionID string, ws interface{}) error {
+	log.Printf("unsubscribe %q", sessionID)
+	e.RLock()
+	_, exists := e.sessions[sessionID]
+	e.RUnlock()
+	if !exists {
+		return errors.New("no session found")
+	}
+
+	e.unsubscribe(sessionID, ws)
+	return nil
+}
+
+// unsubscribe removes the subscriber and tells if it was still subscribed.
+func (e *InMemory) unsubscribe(sessionID string, ws interface{}) bool {
+	e.RLock()
+	s, exists := e.sessions[sessionID]
+	e.RUnlock()
+	if !exists {
+		return false
+	}
+
+	found := false
+	var left *connection
+	s.Lock()
+	if c, ok := s.voters[ws]; ok {
+		close(c)
+		delete(s.voters, ws)
+		found = true
+	}
+
+	if c, ok := s.controllers[ws]; ok {
+		close(c)
+		delete(s.controllers, ws)
+		found = true
+	}
+
+	if c, ok := s.observers[ws]; ok {
+		close(c)
+		delete(s.observers, ws)
+		found = true
+	}
+
+	before, after := Offline, Offline
+	if c, ok := s.connections[ws]; ok {
+		left = c
+		before = presenceOf(s.connections, c.participant)
+		delete(s.connections, ws)
+		after = presenceOf(s.connections, c.participant)
+	}
+	s.Unlock()
+
+	if left != nil && before != after {
+		e.presenceChanged(sessionID, left.participant, after)
+	}
+
+	s.RLock()
+	e.Lock()
+	if len(s.voters)+len(s.controllers)+len(s.observers) == 0 {
+		delete(e.sessions, sessionID)
+	}
+	e.Unlock()
+	s.RUnlock()
+
+	return found
+}
+
+func (e *InMemory) Replay(sessionID string, r Role, after uint64) ([]*Payload, bool) {
+	e.RLock()
+	h, exists := e.histories[sessionID]
+	e.RUnlock()
+	if !exists {
+		return []*Payload{}, after == 0
+	}
+
+	return h.since(r, after)
+}
+
+func (e *InMemory) Sessions() []string {
+	e.RLock()
+	defer e.RUnlock()
+
+	res := make([]string, 0, len(e.histories))
+	for id := range e.histories {
+		res = append(res, id)
+	}
+	for id := range e.sessions {
+		if _, ok := e.histories[id]; !ok {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+func (e *InMemory) Close(sessionID string) {
+	log.Printf("close %q", sessionID)
+	e.Lock()
+	s, exists := e.sessions[sessionID]
+	delete(e.sessions, sessionID)
+	delete(e.histories, sessionID)
+	e.Unlock()
+
+	if !exists {
+		return
+	}
+
+	s.Lock()
+	for ws, c := range s.voters {
+		close(c)
+		delete(s.voters, ws)
+	}
+	for ws, c := range s.controllers {
+		close(c)
+		delete(s.controllers, ws)
+	}
+	for ws, c := range s.observers {
+		close(c)
+		delete(s.observers, ws)
+	}
+	s.Unlock()
+}
+
+func (e *InMemory) Stats() Stats {
+	return Stats{
+		Dropped:      atomic.LoadUint64(&e.dropped),
+		Disconnected: atomic.LoadUint64(&e.disconnected),
+	}
+}
+
+func (e *InMemory) has(sessionID string) bool {
+	e.RLock()
+	defer e.RUnlock()
+
+	_, exists := e.sessions[sessionID]
+	return exists
+}
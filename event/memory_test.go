@@ -0,0 +1,70 @@
+package event_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/akarasz/pajthy-backend/event"
+)
+
+func TestInMemory(t *testing.T) {
+	suite.Run(t, &Suite{New: func() event.Event { return event.NewInMemory() }})
+}
+
+func TestInMemory_Replay(t *testing.T) {
+	e := event.NewInMemory(event.WithReplaySize(3))
+
+	// nothing emitted yet
+	got, complete := e.Replay("id", event.Voter, 0)
+	assert.Empty(t, got)
+	assert.True(t, complete)
+
+	// payloads are kept without subscribers too
+	e.Emit("id", event.Voter, event.Enabled, nil)
+	e.Emit("id", event.Controller, event.Vote, nil)
+	e.Emit("id", event.Voter, event.Disabled, nil)
+
+	got, complete = e.Replay("id", event.Voter, 0)
+	assert.True(t, complete)
+	if assert.Len(t, got, 2) {
+		assert.Exactly(t, uint64(1), got[0].ID)
+		assert.Exactly(t, event.Enabled, got[0].Kind)
+		assert.Exactly(t, uint64(3), got[1].ID)
+		assert.Exactly(t, event.Disabled, got[1].Kind)
+	}
+
+	got, complete = e.Replay("id", event.Controller, 1)
+	assert.True(t, complete)
+	if assert.Len(t, got, 1) {
+		assert.Exactly(t, event.Vote, got[0].Kind)
+	}
+
+	// the oldest is overwritten
+	e.Emit("id", event.Voter, event.Reset, nil)
+
+	got, complete = e.Replay("id", event.Voter, 0)
+	assert.False(t, complete)
+	assert.Len(t, got, 2)
+
+	got, complete = e.Replay("id", event.Voter, 1)
+	assert.True(t, complete)
+	if assert.Len(t, got, 2) {
+		assert.Exactly(t, event.Disabled, got[0].Kind)
+		assert.Exactly(t, event.Reset, got[1].Kind)
+	}
+
+	// subscribers receive the numbered payloads
+	c, err := e.Subscribe("id", event.Voter, "ws")
+	require.NoError(t, err)
+	e.Emit("id", event.Voter, event.Enabled, nil)
+	assert.Exactly(t, uint64(5), (<-c).ID)
+
+	// closing forgets the history
+	e.Close("id")
+	got, complete = e.Replay("id", event.Voter, 4)
+	assert.Empty(t, got)
+	assert.False(t, complete)
+}
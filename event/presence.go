@@ -0,0 +1,124 @@
+package event
+
+type PresenceState string
+
+const (
+	Online  = PresenceState("online")
+	Idle    = PresenceState("idle")
+	Offline = PresenceState("offline")
+)
+
+// PresenceData is emitted to the controllers when the state of a
+// participant changes.
+type PresenceData struct {
+	Participant string
+	State       PresenceState
+}
+
+// Tracker is implemented by the events tracking which participants have live
+// connections. Changes are emitted to the controllers as Presence events.
+type Tracker interface {
+	// Track binds the subscriber ws to the participant. The participant is
+	// online until all of its subscribers are unsubscribed.
+	Track(sessionID string, participant string, ws interface{})
+	// SetIdle marks the subscriber ws idle or active again. The participant
+	// is idle when all of its subscribers are.
+	SetIdle(sessionID string, ws interface{}, idle bool)
+	// Presence returns the state of the participants having subscribers.
+	Presence(sessionID string) map[string]PresenceState
+}
+
+type connection struct {
+	participant string
+	idle        bool
+}
+
+// presenceOf aggregates the state of the participant from its connections.
+func presenceOf(connections map[interface{}]*connection, participant string) PresenceState {
+	res := Offline
+	for _, c := range connections {
+		if c.participant != participant {
+			continue
+		}
+		if !c.idle {
+			return Online
+		}
+		res = Idle
+	}
+	return res
+}
+
+func (e *InMemory) Track(sessionID string, participant string, ws interface{}) {
+	e.RLock()
+	s, exists := e.sessions[sessionID]
+	e.RUnlock()
+	if !exists {
+		return
+	}
+
+	s.Lock()
+	if _, ok := s.voters[ws]; !ok {
+		s.Unlock()
+		return
+	}
+	before := presenceOf(s.connections, participant)
+	s.connections[ws] = &connection{participant: participant}
+	s.Unlock()
+
+	if before != Online {
+		e.presenceChanged(sessionID, participant, Online)
+	}
+}
+
+func (e *InMemory) SetIdle(sessionID string, ws interface{}, idle bool) {
+	e.RLock()
+	s, exists := e.sessions[sessionID]
+	e.RUnlock()
+	if !exists {
+		return
+	}
+
+	s.Lock()
+	c, ok := s.connections[ws]
+	if !ok {
+		s.Unlock()
+		return
+	}
+	before := presenceOf(s.connections, c.participant)
+	c.idle = idle
+	after := presenceOf(s.connections, c.participant)
+	s.Unlock()
+
+	if before != after {
+		e.presenceChanged(sessionID, c.participant, after)
+	}
+}
+
+func (e *InMemory) Presence(sessionID string) map[string]PresenceState {
+	res := map[string]PresenceState{}
+
+	e.RLock()
+	s, exists := e.sessions[sessionID]
+	e.RUnlock()
+	if !exists {
+		return res
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+	for _, c := range s.connections {
+		res[c.participant] = presenceOf(s.connections, c.participant)
+	}
+	return res
+}
+
+// presenceChanged emits the new state to the controllers unless it is
+// handled by the owner.
+func (e *InMemory) presenceChanged(sessionID string, participant string, state PresenceState) {
+	d := &PresenceData{Participant: participant, State: state}
+	if e.onPresence != nil {
+		e.onPresence(sessionID, d)
+		return
+	}
+	e.emit(sessionID, Controller, NewPayload(Presence, d))
+}
@@ -0,0 +1,302 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// Redis fans out the events to the subscribers of every instance sharing the
+// same redis server. Subscribers of the emitting instance are notified
+// directly, others through redis pub/sub. The payloads are numbered and the
+// recent ones kept in redis, so subscribers can resume on any instance.
+type Redis struct {
+	sync.Mutex
+
+	local      *InMemory
+	client     *redis.Client
+	pubsub     *redis.PubSub
+	origin     string
+	replaySize int
+}
+
+type redisMessage struct {
+	// ID is left out when encoding, the publishing script puts it first.
+	ID        uint64 `json:",omitempty"`
+	Origin    string
+	SessionID string
+	Role      Role
+	Kind      Type
+	Data      json.RawMessage
+}
+
+// redisHistoryTTL is how long the numbering and the recent payloads of an
+// idle session are kept.
+const redisHistoryTTL = 24 * time.Hour
+
+// redisPublish numbers the message, keeps it in the history of the session
+// and publishes it. The id is returned.
+var redisPublish = redis.NewScript(`
+local id = redis.call("INCR", KEYS[1])
+local msg = '{"ID":' .. id .. ',' .. string.sub(ARGV[1], 2)
+if tonumber(ARGV[2]) > 0 then
+	redis.call("RPUSH", KEYS[2], msg)
+	redis.call("LTRIM", KEYS[2], -tonumber(ARGV[2]), -1)
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[3])
+redis.call("PEXPIRE", KEYS[2], ARGV[3])
+redis.call("PUBLISH", KEYS[3], msg)
+return id
+`)
+
+func NewRedis(o *redis.Options, opts ...Option) *Redis {
+	client := redis.NewClient(o)
+
+	options := newOptions(opts)
+	res := &Redis{
+		// the history is kept in redis
+		local:      NewInMemory(append(opts, WithReplaySize(0))...),
+		client:     client,
+		pubsub:     client.Subscribe(context.TODO()),
+		origin:     uuid.Must(uuid.NewRandom()).String(),
+		replaySize: options.replaySize,
+	}
+	res.local.onPresence = res.presenceChanged
+	go res.receive()
+
+	return res
+}
+
+func redisChannel(sessionID string) string {
+	return "pajthy:event:" + sessionID
+}
+
+func redisSeqKey(sessionID string) string {
+	return "pajthy:event:" + sessionID + ":seq"
+}
+
+func redisHistoryKey(sessionID string) string {
+	return "pajthy:event:" + sessionID + ":history"
+}
+
+// redisPresenceKey is a hash of the participant states on every instance,
+// keyed by origin:participant.
+func redisPresenceKey(sessionID string) string {
+	return "pajthy:event:" + sessionID + ":presence"
+}
+
+func (e *Redis) receive() {
+	for msg := range e.pubsub.Channel() {
+		var m redisMessage
+		if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil {
+			log.Printf("redis message decoding: %v", err)
+			continue
+		}
+
+		if m.Origin == e.origin {
+			continue
+		}
+
+		e.local.emit(m.SessionID, m.Role, m.payload())
+	}
+}
+
+func (m *redisMessage) payload() *Payload {
+	res := NewPayload(m.Kind, m.Data)
+	res.ID = m.ID
+	return res
+}
+
+// Shutdown stops listening on redis. The instance is unusable afterwards.
+func (e *Redis) Shutdown() error {
+	return e.pubsub.Close()
+}
+
+func (e *Redis) Emit(sessionID string, r Role, t Type, body interface{}) {
+	p := NewPayload(t, body)
+	defer func() {
+		e.local.emit(sessionID, r, p)
+	}()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("redis message encoding: %v", err)
+		return
+	}
+
+	msg, err := json.Marshal(&redisMessage{
+		Origin:    e.origin,
+		SessionID: sessionID,
+		Role:      r,
+		Kind:      t,
+		Data:      data,
+	})
+	if err != nil {
+		log.Printf("redis message encoding: %v", err)
+		return
+	}
+
+	id, err := redisPublish.Run(context.TODO(), e.client,
+		[]string{redisSeqKey(sessionID), redisHistoryKey(sessionID), redisChannel(sessionID)},
+		msg, e.replaySize, redisHistoryTTL.Milliseconds()).Int64()
+	if err != nil {
+		log.Printf("redis publish: %v", err)
+		return
+	}
+	p.ID = uint64(id)
+}
+
+func (e *Redis) Replay(sessionID string, r Role, after uint64) ([]*Payload, bool) {
+	last, err := e.client.Get(context.TODO(), redisSeqKey(sessionID)).Uint64()
+	if err == redis.Nil {
+		return []*Payload{}, after == 0
+	}
+	if err != nil {
+		log.Printf("redis replay: %v", err)
+		return nil, false
+	}
+
+	raw, err := e.client.LRange(context.TODO(), redisHistoryKey(sessionID), 0, -1).Result()
+	if err != nil {
+		log.Printf("redis replay: %v", err)
+		return nil, false
+	}
+
+	res := []*Payload{}
+	complete := after == last
+	for _, msg := range raw {
+		var m redisMessage
+		if err := json.Unmarshal([]byte(msg), &m); err != nil {
+			log.Printf("redis message decoding: %v", err)
+			return nil, false
+		}
+
+		if m.ID == after+1 {
+			complete = true
+		}
+		if m.ID > after && m.Role == r {
+			res = append(res, m.payload())
+		}
+	}
+	return res, complete
+}
+
+func (e *Redis) Subscribe(sessionID string, r Role, ws interface{}) (chan *Payload, error) {
+	e.Lock()
+	defer e.Unlock()
+
+	c, err := e.local.Subscribe(sessionID, r, ws)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.pubsub.Subscribe(context.TODO(), redisChannel(sessionID)); err != nil {
+		e.local.Unsubscribe(sessionID, ws)
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (e *Redis) Unsubscribe(sessionID string, ws interface{}) error {
+	e.Lock()
+	defer e.Unlock()
+
+	// the local subscriber could be already evicted for being slow, the redis
+	// channel still has to be released with the last one
+	err := e.local.Unsubscribe(sessionID, ws)
+
+	if e.local.has(sessionID) {
+		return err
+	}
+
+	if uerr := e.pubsub.Unsubscribe(context.TODO(), redisChannel(sessionID)); uerr != nil {
+		return uerr
+	}
+	return err
+}
+
+func (e *Redis) Stats() Stats {
+	return e.local.Stats()
+}
+
+func (e *Redis) Sessions() []string {
+	return e.local.Sessions()
+}
+
+func (e *Redis) Close(sessionID string) {
+	e.Lock()
+	defer e.Unlock()
+
+	e.local.Close(sessionID)
+
+	if err := e.client.Del(context.TODO(), redisSeqKey(sessionID), redisHistoryKey(sessionID), redisPresenceKey(sessionID)).Err(); err != nil {
+		log.Printf("redis delete: %v", err)
+	}
+	if err := e.pubsub.Unsubscribe(context.TODO(), redisChannel(sessionID)); err != nil {
+		log.Printf("redis unsubscribe: %v", err)
+	}
+}
+
+func (e *Redis) Track(sessionID string, participant string, ws interface{}) {
+	e.local.Track(sessionID, participant, ws)
+}
+
+func (e *Redis) SetIdle(sessionID string, ws interface{}, idle bool) {
+	e.local.SetIdle(sessionID, ws, idle)
+}
+
+func (e *Redis) Presence(sessionID string) map[string]PresenceState {
+	res := map[string]PresenceState{}
+
+	fields, err := e.client.HGetAll(context.TODO(), redisPresenceKey(sessionID)).Result()
+	if err != nil {
+		log.Printf("redis presence: %v", err)
+		return res
+	}
+
+	for field, state := range fields {
+		parts := strings.SplitN(field, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		participant := parts[1]
+		if res[participant] != Online {
+			res[participant] = PresenceState(state)
+		}
+	}
+	return res
+}
+
+// presenceChanged shares the state of the participant on this instance and
+// emits the one aggregated from every instance.
+func (e *Redis) presenceChanged(sessionID string, d *PresenceData) {
+	key := redisPresenceKey(sessionID)
+	field := e.origin + ":" + d.Participant
+
+	var err error
+	if d.State == Offline {
+		err = e.client.HDel(context.TODO(), key, field).Err()
+	} else {
+		_, err = e.client.TxPipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+			pipe.HSet(context.TODO(), key, field, string(d.State))
+			pipe.PExpire(context.TODO(), key, redisHistoryTTL)
+			return nil
+		})
+	}
+	if err != nil {
+		log.Printf("redis presence: %v", err)
+	}
+
+	state, ok := e.Presence(sessionID)[d.Participant]
+	if !ok {
+		state = Offline
+	}
+	e.Emit(sessionID, Controller, Presence, &PresenceData{Participant: d.Participant, State: state})
+}
@@ -0,0 +1,112 @@
+package event_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/akarasz/pajthy-backend/event"
+)
+
+func TestRedis(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+
+	suite.Run(t, &Suite{New: func() event.Event {
+		e := event.NewRedis(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { e.Shutdown() })
+		return e
+	}})
+}
+
+func TestRedis_AcrossInstances(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	defer server.Close()
+
+	a := event.NewRedis(&redis.Options{Addr: server.Addr()})
+	defer a.Shutdown()
+	b := event.NewRedis(&redis.Options{Addr: server.Addr()})
+	defer b.Shutdown()
+
+	alice := mustSubscribe(t, a, "acrossID", event.Voter, "alice")
+	bob := mustSubscribe(t, b, "acrossID", event.Voter, "bob")
+	carol := mustSubscribe(t, b, "acrossID", event.Controller, "carol")
+	waitForRedisSubscribers(t, server, "pajthy:event:acrossID", 2)
+
+	// events emitted on one instance reach the subscribers of the others
+	go a.Emit("acrossID", event.Voter, event.Vote, map[string]string{"Alice": "red"})
+
+	if got := <-receivePayload(alice); assert.NotNil(t, got) {
+		assert.Exactly(t, event.Vote, got.Kind)
+	}
+	if got := <-receivePayload(bob); assert.NotNil(t, got) {
+		assert.Exactly(t, event.Vote, got.Kind)
+		assert.JSONEq(t, `{"Alice": "red"}`, string(got.Data.(json.RawMessage)))
+	}
+	assert.Nil(t, <-receivePayload(carol))
+
+	// the numbering and the history are shared
+	got, complete := b.Replay("acrossID", event.Voter, 0)
+	assert.True(t, complete)
+	if assert.Len(t, got, 1) {
+		assert.Exactly(t, uint64(1), got[0].ID)
+		assert.Exactly(t, event.Vote, got[0].Kind)
+	}
+
+	// presence is shared
+	b.Track("acrossID", "Bob", "bob")
+	if got := <-receivePayload(carol); assert.NotNil(t, got) {
+		assert.Exactly(t, event.Presence, got.Kind)
+	}
+	assert.Exactly(t, map[string]event.PresenceState{"Bob": event.Online}, a.Presence("acrossID"))
+
+	// after the last unsubscribe the instance stops listening
+	require.NoError(t, b.Unsubscribe("acrossID", "bob"))
+	require.NoError(t, b.Unsubscribe("acrossID", "carol"))
+	waitForRedisSubscribers(t, server, "pajthy:event:acrossID", 1)
+}
+
+func TestRedis_ReplayOverflow(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	defer server.Close()
+
+	e := event.NewRedis(&redis.Options{Addr: server.Addr()}, event.WithReplaySize(2))
+	defer e.Shutdown()
+
+	for i := 0; i < 3; i++ {
+		e.Emit("overflowID", event.Voter, event.Enabled, nil)
+	}
+
+	got, complete := e.Replay("overflowID", event.Voter, 0)
+	assert.False(t, complete)
+	assert.Len(t, got, 2)
+
+	got, complete = e.Replay("overflowID", event.Voter, 1)
+	assert.True(t, complete)
+	assert.Len(t, got, 2)
+
+	// closing forgets the history
+	e.Close("overflowID")
+	got, complete = e.Replay("overflowID", event.Voter, 0)
+	assert.True(t, complete)
+	assert.Empty(t, got)
+}
+
+func waitForRedisSubscribers(t *testing.T, server *miniredis.Miniredis, channel string, want int) {
+	for i := 0; i < 100; i++ {
+		if server.PubSubNumSub(channel)[channel] == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%q has no %d subscribers", channel, want)
+}
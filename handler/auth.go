@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type TokenResponse struct {
+	Token string
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// tokenFrom extracts the secret of the caller either from a bearer
+// authorization header or, for websockets where browsers can't set headers,
+// from the token query parameter.
+func tokenFrom(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return r.URL.Query().Get("token")
+}
+
+func (h *Handler) controllerOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["session"]
+
+		s, err := h.store.Load(id)
+		if err != nil {
+			showStoreError(w, err)
+			return
+		}
+
+		if !s.Data.IsController(tokenFrom(r)) {
+			showError(w, http.StatusUnauthorized, "unauthorized", errUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akarasz/pajthy-backend/domain"
+	"github.com/akarasz/pajthy-backend/store"
+)
+
+// readChoices returns the choices in the body, given as a list of choices or
+// of cards, or the cards of the preset given in the deck query parameter. The
+// cards are returned with their choices.
+func readChoices(w http.ResponseWriter, r *http.Request) ([]string, []domain.Card, error) {
+	name := r.URL.Query().Get("deck")
+	if name == "" {
+		var body json.RawMessage
+		if err := readContent(w, r, &body); err != nil {
+			return nil, nil, err
+		}
+
+		var choices []string
+		if err := json.Unmarshal(body, &choices); err == nil {
+			return choices, nil, nil
+		}
+
+		var deck []domain.Card
+		if err := json.Unmarshal(body, &deck); err != nil {
+			showError(w, http.StatusInternalServerError, "request json decoding", err)
+			return nil, nil, err
+		}
+		for _, c := range deck {
+			if c.Choice == "" {
+				showError(w, http.StatusBadRequest, "not a valid deck", nil)
+				return nil, nil, errInvalidDeck
+			}
+		}
+		return domain.Choices(deck), deck, nil
+	}
+
+	deck, ok := domain.Deck(name)
+	if !ok {
+		showError(w, http.StatusBadRequest, "not a valid deck", nil)
+		return nil, nil, errInvalidDeck
+	}
+	return domain.Choices(deck), deck, nil
+}
+
+func (h *Handler) replaceChoices(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["session"]
+
+	choices, deck, err := readChoices(w, r)
+	if err != nil {
+		return
+	}
+
+	log.Printf("replace choices %q %v", id, choices)
+
+	h.changeChoices(w, id, http.StatusAccepted, func(s *domain.Session) error {
+		if err := s.SetChoices(choices); err != nil {
+			return err
+		}
+		if deck != nil {
+			s.Deck = deck
+		}
+		return nil
+	})
+}
+
+func (h *Handler) addChoice(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["session"]
+
+	var choice string
+	if err := readContent(w, r, &choice); err != nil {
+		return
+	}
+
+	log.Printf("add choice %q %q", id, choice)
+
+	h.changeChoices(w, id, http.StatusCreated, func(s *domain.Session) error {
+		return s.AddChoice(choice)
+	})
+}
+
+func (h *Handler) removeChoice(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["session"]
+	n, _ := strconv.Atoi(mux.Vars(r)["n"])
+
+	log.Printf("remove choice %q %d", id, n)
+
+	h.changeChoices(w, id, http.StatusNoContent, func(s *domain.Session) error {
+		if n >= len(s.Choices) {
+			return errInvalidChoice
+		}
+		return s.RemoveChoice(n)
+	})
+}
+
+func (h *Handler) reorderChoices(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["session"]
+
+	var order []int
+	if err := readContent(w, r, &order); err != nil {
+		return
+	}
+
+	log.Printf("reorder choices %q %v", id, order)
+
+	h.changeChoices(w, id, http.StatusAccepted, func(s *domain.Session) error {
+		return s.ReorderChoices(order)
+	})
+}
+
+// changeChoices applies the change when no round is open, responding with the
+// given status on success and telling everybody about the new choices.
+func (h *Handler) changeChoices(w http.ResponseWriter, id string, status int, change func(*domain.Session) error) {
+	saved, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
+		if s.Open {
+			return nil, errOpenRound
+		}
+		if err := change(s); err != nil {
+			return nil, err
+		}
+
+		return s, nil
+	})
+
+	switch err {
+	case nil:
+		w.WriteHeader(status)
+	case errOpenRound:
+		showError(w, http.StatusConflict, "round is open", nil)
+		return
+	case errInvalidChoice:
+		showError(w, http.StatusNotFound, "choice not exists", nil)
+		return
+	case domain.ErrInvalidChoices:
+		showError(w, http.StatusBadRequest, "not valid choices", nil)
+		return
+	case domain.ErrInvalidOrder:
+		showError(w, http.StatusBadRequest, "not a valid order", nil)
+		return
+	case store.ErrVersionMismatch:
+		showError(w, http.StatusInternalServerError, "locking error, try again later", nil)
+		return
+	default:
+		showStoreError(w, err)
+		return
+	}
+
+	h.emitChoicesChange(id, saved)
+}
@@ -5,24 +5,38 @@ import (
 	"log"
 	"math/rand"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 
 	"github.com/akarasz/pajthy-backend/domain"
+	"github.com/akarasz/pajthy-backend/event"
 	"github.com/akarasz/pajthy-backend/store"
 )
 
+// createSession creates a session with the choices in the body, or with the
+// cards of the preset given in the deck query parameter.
 func (h *Handler) createSession(w http.ResponseWriter, r *http.Request) {
 	log.Print("create session")
 
-	var choices []string
-	if err := readContent(w, r, &choices); err != nil {
+	choices, deck, err := readChoices(w, r)
+	if err != nil {
+		return
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		showError(w, http.StatusInternalServerError, "token generation", err)
 		return
 	}
 
 	id := generateID()
 	s := domain.NewSession()
 	s.Choices = choices
+	if deck != nil {
+		s.Deck = deck
+	}
+	s.ControllerHash = domain.HashToken(token)
 
 	if err := h.store.Save(id, s); err != nil {
 		showStoreError(w, err)
@@ -30,7 +44,9 @@ func (h *Handler) createSession(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Location", fmt.Sprintf("/%s", id))
-	w.WriteHeader(http.StatusCreated)
+	if err := showJSONWithStatus(w, http.StatusCreated, &TokenResponse{Token: token}); err != nil {
+		return
+	}
 }
 
 func generateID() string {
@@ -46,30 +62,78 @@ func generateID() string {
 	return string(b)
 }
 
+type SessionResponse struct {
+	Choices      []string
+	Deck         []domain.Card `json:",omitempty"`
+	Participants []string
+	SittingOut   []string `json:",omitempty"`
+	Votes        map[string]string
+	Counts       map[string]int `json:",omitempty"`
+	Voted        []string       `json:",omitempty"`
+	Open         bool
+	Deadline     *time.Time `json:",omitempty"`
+	Presence     map[string]event.PresenceState
+	Observers    []string
+	Settings     domain.Settings
+}
+
 func (h *Handler) getSession(w http.ResponseWriter, r *http.Request) {
 	session := mux.Vars(r)["session"]
 
 	log.Printf("get session %q", session)
 
-	s, err := h.store.Load(session)
+	s, err := h.load(session)
 	if err != nil {
 		showStoreError(w, err)
 		return
 	}
 
-	if err := showJSON(w, s.Data); err != nil {
+	votes, counts, voted := controllerVotes(s.Data)
+	res := &SessionResponse{
+		Choices:      s.Data.Choices,
+		Deck:         s.Data.Deck,
+		Participants: s.Data.Participants,
+		SittingOut:   s.Data.SittingOut,
+		Votes:        votes,
+		Counts:       counts,
+		Voted:        voted,
+		Open:         s.Data.Open,
+		Deadline:     deadlineOf(s.Data),
+		Presence:     h.presence(session, s.Data.Participants),
+		Observers:    s.Data.Observers,
+		Settings:     s.Data.Settings,
+	}
+
+	if err := showJSON(w, res); err != nil {
 		return
 	}
 }
 
+// StartRequest opens a round on the topic. The round is closed after the
+// Duration, like "90s" or "5m", when given.
+type StartRequest struct {
+	Topic    string
+	Duration string
+}
+
 func (h *Handler) startVote(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["session"]
 
-	log.Printf("start vote %q", id)
+	var req StartRequest
+	if err := readOptionalContent(w, r, &req); err != nil {
+		return
+	}
 
-	_, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
-		s.Open = true
-		s.Votes = map[string]string{}
+	duration, err := parseDuration(w, req.Duration)
+	if err != nil {
+		return
+	}
+
+	log.Printf("start vote %q %q", id, req.Topic)
+
+	var archived *domain.Round
+	saved, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
+		archived, _ = s.Start(time.Now(), req.Topic, duration)
 
 		return s, nil
 	})
@@ -85,8 +149,26 @@ func (h *Handler) startVote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.emitVoteEnabled(id)
+	if archived != nil {
+		h.emitRoundArchived(id, len(saved.Rounds)-1, archived)
+	}
+	h.emitVoteEnabled(id, saved)
+	h.scheduleDeadline(id, saved)
+}
+
+// parseDuration returns the duration of the round in the request, zero if it
+// is not given.
+func parseDuration(w http.ResponseWriter, duration string) (time.Duration, error) {
+	if duration == "" {
+		return 0, nil
+	}
 
+	d, err := time.ParseDuration(duration)
+	if err != nil || d <= 0 {
+		showError(w, http.StatusBadRequest, "not a valid duration", err)
+		return 0, errInvalidDuration
+	}
+	return d, nil
 }
 
 func (h *Handler) stopVote(w http.ResponseWriter, r *http.Request) {
@@ -94,8 +176,9 @@ func (h *Handler) stopVote(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("stop vote %q", id)
 
-	_, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
-		s.Open = false
+	var archived *domain.Round
+	saved, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
+		archived, _ = s.Close(time.Now())
 
 		return s, nil
 	})
@@ -111,7 +194,7 @@ func (h *Handler) stopVote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.emitVoteDisabled(id)
+	h.emitClosed(id, saved, archived)
 }
 
 func (h *Handler) resetVote(w http.ResponseWriter, r *http.Request) {
@@ -119,9 +202,12 @@ func (h *Handler) resetVote(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("reset vote %q", id)
 
+	var archived *domain.Round
 	saved, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
+		archived, _ = s.Archive(time.Now())
+
 		s.Open = false
-		s.Votes = map[string]string{}
+		s.ClearVotes()
 
 		return s, nil
 	})
@@ -137,9 +223,11 @@ func (h *Handler) resetVote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if archived != nil {
+		h.emitRoundArchived(id, len(saved.Rounds)-1, archived)
+	}
 	h.emitReset(id)
-	h.emitVote(id, saved.Votes)
-
+	h.emitVote(id, saved)
 }
 
 func (h *Handler) kickParticipant(w http.ResponseWriter, r *http.Request) {
@@ -152,18 +240,17 @@ func (h *Handler) kickParticipant(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("kick participant %q %q", id, name)
 
+	connected := h.connected(id)
+
+	var open bool
+	var closing time.Time
 	saved, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
-		idx := -1
-		for i, p := range s.Participants {
-			if p == name {
-				idx = i
-				break
-			}
-		}
-		if idx < 0 {
+		open, closing = s.Open, s.Closing
+
+		if !s.RemoveParticipant(name) {
 			return nil, errInvalidParticipant
 		}
-		s.Participants = append(s.Participants[:idx], s.Participants[idx+1:]...)
+		s.Settle(time.Now(), connected)
 
 		return s, nil
 	})
@@ -182,5 +269,7 @@ func (h *Handler) kickParticipant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.emitParticipantsChange(id, saved.Participants)
+	h.emitParticipantsChange(id, saved)
+	h.emitVote(id, saved)
+	h.emitSettled(id, saved, open, closing)
 }
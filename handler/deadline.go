@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/akarasz/pajthy-backend/domain"
+	"github.com/akarasz/pajthy-backend/store"
+)
+
+var (
+	errNotExpired      = errors.New("not expired")
+	errInvalidDuration = errors.New("invalid duration")
+)
+
+// load returns the session after closing its round if the deadline passed
+// without being noticed, like when there was no process running to do it.
+func (h *Handler) load(id string) (*store.Session, error) {
+	s, err := h.store.Load(id)
+	if err != nil || !s.Data.Expired(time.Now()) {
+		return s, err
+	}
+
+	h.closeExpired(id)
+	return h.store.Load(id)
+}
+
+// scheduleClose closes the round when its deadline passes.
+func (h *Handler) scheduleClose(id string, deadline time.Time) {
+	time.AfterFunc(time.Until(deadline), func() {
+		h.closeExpired(id)
+	})
+}
+
+// scheduleDeadline closes the round when its deadline passes, if it has one.
+func (h *Handler) scheduleDeadline(id string, s *domain.Session) {
+	if !s.Deadline.IsZero() {
+		h.scheduleClose(id, s.Deadline)
+	}
+}
+
+// closeExpired closes the round the same way stopping it does, if it is open
+// after its deadline.
+func (h *Handler) closeExpired(id string) {
+	var archived *domain.Round
+	saved, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
+		now := time.Now()
+		if !s.Expired(now) {
+			return nil, errNotExpired
+		}
+		archived, _ = s.Close(now)
+
+		return s, nil
+	})
+
+	switch err {
+	case nil:
+	case errNotExpired, store.ErrNotExists:
+		return
+	default:
+		log.Printf("closing expired round %q: %v", id, err)
+		return
+	}
+
+	log.Printf("deadline passed %q", id)
+	h.emitClosed(id, saved, archived)
+}
+
+// emitClosed tells about the round closed, revealing the votes to the
+// controllers if they were hidden. Archived is the round moved to the history
+// by closing it, if any.
+func (h *Handler) emitClosed(id string, s *domain.Session, archived *domain.Round) {
+	h.emitVoteDisabled(id)
+	if s.Settings.HideChoices {
+		h.emitVote(id, s)
+	}
+	h.emitDone(id, s)
+	if archived != nil {
+		h.emitRoundArchived(id, len(s.Rounds)-1, archived)
+	}
+}
+
+// emitSettled tells about the round closed, or about to be closed after the
+// grace period, by the auto-close policy. Open and closing are the state of
+// the round before the change.
+func (h *Handler) emitSettled(id string, s *domain.Session, open bool, closing time.Time) {
+	switch {
+	case open && !s.Open:
+		var archived *domain.Round
+		if s.Revealed() {
+			r := s.Rounds[len(s.Rounds)-1]
+			archived = &r
+		}
+		h.emitClosed(id, s, archived)
+	case !s.Closing.Equal(closing):
+		h.emitVoteEnabled(id, s)
+		if !s.Closing.IsZero() {
+			h.scheduleClose(id, s.Closing)
+		}
+	}
+}
+
+// deadlineOf returns when the open round gets closed, by its deadline or by
+// the end of the grace period, nil if it is not known.
+func deadlineOf(s *domain.Session) *time.Time {
+	if !s.Open {
+		return nil
+	}
+
+	var res *time.Time
+	for _, t := range []time.Time{s.Deadline, s.Closing} {
+		if t := t; !t.IsZero() && (res == nil || t.Before(*res)) {
+			res = &t
+		}
+	}
+	return res
+}
@@ -15,7 +15,7 @@ import (
 
 type Handler struct {
 	store store.Store
-	event *event.Event
+	event event.Event
 }
 
 var (
@@ -23,9 +23,16 @@ var (
 	errClosedSession      = errors.New("session is closed")
 	errInvalidParticipant = errors.New("not a valid participant")
 	errInvalidChoice      = errors.New("not a valid choice")
+	errUnauthorized       = errors.New("unauthorized")
+	errInvalidRound       = errors.New("not a valid round")
+	errInvalidItem        = errors.New("not a valid item")
+	errNoMoreItems        = errors.New("no more items")
+	errVotesCast          = errors.New("votes are already cast")
+	errOpenRound          = errors.New("round is open")
+	errInvalidDeck        = errors.New("not a valid deck")
 )
 
-func New(s store.Store, e *event.Event) http.Handler {
+func New(s store.Store, e event.Event) http.Handler {
 	h := &Handler{
 		store: s,
 		event: e,
@@ -39,22 +46,67 @@ func New(s store.Store, e *event.Event) http.Handler {
 		Methods("GET", "OPTIONS")
 	r.HandleFunc("/{session}", h.vote).
 		Methods("PUT", "OPTIONS")
-	r.HandleFunc("/{session}/control", h.getSession).
+	r.HandleFunc("/{session}/join", h.join).
+		Methods("PUT", "OPTIONS")
+	r.HandleFunc("/{session}/vote", h.retractVote).
+		Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/{session}/sit-out", h.sitOut).
+		Methods("PATCH", "OPTIONS")
+	r.HandleFunc("/{session}/ws", h.ws).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/{session}/events", h.events).
 		Methods("GET", "OPTIONS")
-	r.HandleFunc("/{session}/control/start", h.startVote).
+	r.HandleFunc("/{session}/observe", h.observe).
+		Methods("PUT", "OPTIONS")
+	r.HandleFunc("/{session}/observe/ws", h.observeWS).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/{session}/observe/events", h.observeEvents).
+		Methods("GET", "OPTIONS")
+
+	c := r.PathPrefix("/{session}/control").Subrouter()
+	c.Use(h.controllerOnly)
+	c.HandleFunc("", h.getSession).
+		Methods("GET", "OPTIONS")
+	c.HandleFunc("/start", h.startVote).
 		Methods("PATCH", "OPTIONS")
-	r.HandleFunc("/{session}/control/stop", h.stopVote).
+	c.HandleFunc("/stop", h.stopVote).
 		Methods("PATCH", "OPTIONS")
-	r.HandleFunc("/{session}/control/reset", h.resetVote).
+	c.HandleFunc("/reset", h.resetVote).
 		Methods("PATCH", "OPTIONS")
-	r.HandleFunc("/{session}/control/kick", h.kickParticipant).
+	c.HandleFunc("/kick", h.kickParticipant).
 		Methods("PATCH", "OPTIONS")
-	r.HandleFunc("/{session}/control/ws", h.controlWS).
-		Methods("GET", "OPTIONS")
-	r.HandleFunc("/{session}/join", h.join).
+	c.HandleFunc("/choices", h.replaceChoices).
 		Methods("PUT", "OPTIONS")
-	r.HandleFunc("/{session}/ws", h.ws).
+	c.HandleFunc("/choices", h.addChoice).
+		Methods("POST", "OPTIONS")
+	c.HandleFunc("/choices/order", h.reorderChoices).
+		Methods("PATCH", "OPTIONS")
+	c.HandleFunc("/choices/{n:[0-9]+}", h.removeChoice).
+		Methods("DELETE", "OPTIONS")
+	c.HandleFunc("/settings", h.updateSettings).
+		Methods("PATCH", "OPTIONS")
+	c.HandleFunc("/ws", h.controlWS).
+		Methods("GET", "OPTIONS")
+	c.HandleFunc("/events", h.controlEvents).
 		Methods("GET", "OPTIONS")
+	c.HandleFunc("/result", h.result).
+		Methods("GET", "OPTIONS")
+	c.HandleFunc("/rounds", h.rounds).
+		Methods("GET", "OPTIONS")
+	c.HandleFunc("/rounds/{n:[0-9]+}", h.round).
+		Methods("GET", "OPTIONS")
+	c.HandleFunc("/items", h.items).
+		Methods("GET", "OPTIONS")
+	c.HandleFunc("/items", h.addItem).
+		Methods("POST", "OPTIONS")
+	c.HandleFunc("/items/order", h.reorderItems).
+		Methods("PATCH", "OPTIONS")
+	c.HandleFunc("/items/{n:[0-9]+}", h.updateItem).
+		Methods("PUT", "OPTIONS")
+	c.HandleFunc("/items/{n:[0-9]+}", h.removeItem).
+		Methods("DELETE", "OPTIONS")
+	c.HandleFunc("/next", h.nextItem).
+		Methods("PATCH", "OPTIONS")
 
 	return r
 }
@@ -66,6 +118,7 @@ func corsMiddleware(next http.Handler) http.Handler {
 
 		if r.Method == "OPTIONS" {
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, HEAD, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Last-Event-ID")
 			return
 		}
 
@@ -79,6 +132,28 @@ func readContent(w http.ResponseWriter, r *http.Request, dest interface{}) error
 		showError(w, http.StatusBadRequest, "wrong body", err)
 		return err
 	}
+	return decodeContent(w, rawBody, dest)
+}
+
+// readOptionalContent works like readContent but leaves dest untouched when
+// the request has no body.
+func readOptionalContent(w http.ResponseWriter, r *http.Request, dest interface{}) error {
+	if r.Body == nil {
+		return nil
+	}
+
+	rawBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		showError(w, http.StatusBadRequest, "wrong body", err)
+		return err
+	}
+	if len(rawBody) == 0 {
+		return nil
+	}
+	return decodeContent(w, rawBody, dest)
+}
+
+func decodeContent(w http.ResponseWriter, rawBody []byte, dest interface{}) error {
 	switch dest.(type) {
 	case *string:
 		*dest.(*string) = string(rawBody)
@@ -93,11 +168,19 @@ func readContent(w http.ResponseWriter, r *http.Request, dest interface{}) error
 }
 
 func showJSON(w http.ResponseWriter, payload interface{}) error {
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(payload); err != nil {
+	return showJSONWithStatus(w, http.StatusOK, payload)
+}
+
+func showJSONWithStatus(w http.ResponseWriter, code int, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
 		showError(w, http.StatusInternalServerError, "response json encoding", err)
 		return err
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(body)
 	return nil
 }
 
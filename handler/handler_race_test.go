@@ -18,7 +18,7 @@ import (
 
 func TestParallelActions(t *testing.T) {
 	s := store.NewInMemory()
-	e := event.New()
+	e := event.NewInMemory()
 	r := handler.New(s, e)
 
 	wg := &sync.WaitGroup{}
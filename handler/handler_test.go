@@ -1,6 +1,9 @@
 package handler_test
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -37,7 +40,63 @@ func TestCreateSession(t *testing.T) {
 	if assert.Contains(t, rr.HeaderMap, "Location") && assert.Len(t, rr.HeaderMap["Location"], 1) {
 		got := readFromStore(t, s, strings.TrimLeft(rr.HeaderMap["Location"][0], "/"))
 		assert.Exactly(t, []string{"one", "two"}, got.Choices)
+
+		// controller token is returned and only its hash is stored
+		var res handler.TokenResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		assert.NotEmpty(t, res.Token)
+		assert.Exactly(t, domain.HashToken(res.Token), got.ControllerHash)
+	}
+
+	// creating by deck preset
+	rr = newRequest(t, r, "POST", "/?deck=powers-of-two", nil)
+	if assert.Exactly(t, http.StatusCreated, rr.Code) {
+		got := readFromStore(t, s, strings.TrimLeft(rr.HeaderMap["Location"][0], "/"))
+		assert.Exactly(t, []string{"0", "1", "2", "4", "8", "16", "32", "64", "?", "coffee"}, got.Choices)
+		assert.Exactly(t, domain.Card{Choice: "coffee", Label: "☕"}, got.Card("coffee"))
+		assert.Exactly(t, domain.Card{Choice: "16", Label: "16", Value: 16, Counts: true}, got.Card("16"))
+	}
+
+	// unknown presets return 400
+	rr = newRequest(t, r, "POST", "/?deck=tarot", nil)
+	assert.Exactly(t, http.StatusBadRequest, rr.Code)
+
+	// creating by a list of cards
+	rr = newRequest(t, r, "POST", "/", `[{"Choice": "S", "Value": 2, "Counts": true}, {"Choice": "?"}]`)
+	if assert.Exactly(t, http.StatusCreated, rr.Code) {
+		got := readFromStore(t, s, strings.TrimLeft(rr.HeaderMap["Location"][0], "/"))
+		assert.Exactly(t, []string{"S", "?"}, got.Choices)
+		assert.Exactly(t, domain.Card{Choice: "S", Value: 2, Counts: true}, got.Card("S"))
 	}
+
+	// cards without a choice return 400
+	rr = newRequest(t, r, "POST", "/", `[{"Label": "S"}]`)
+	assert.Exactly(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestControllerAuth(t *testing.T) {
+	s := store.NewInMemory()
+	r := handler.New(s, nil)
+
+	insertToStore(t, s, "abcde", sessionWithChoices("yes", "no"))
+
+	// requests without token are rejected
+	r1 := newRequest(t, r, "PATCH", "/abcde/control/start", nil)
+	assert.Exactly(t, http.StatusUnauthorized, r1.Code)
+
+	// requests with a wrong token are rejected
+	r2 := newRequestWithToken(t, r, "PATCH", "/abcde/control/reset", "wrong", nil)
+	assert.Exactly(t, http.StatusUnauthorized, r2.Code)
+
+	r3 := newRequest(t, r, "GET", "/abcde/control?token=wrong", nil)
+	assert.Exactly(t, http.StatusUnauthorized, r3.Code)
+
+	// token is accepted as a query parameter too
+	r4 := newRequest(t, r, "GET", "/abcde/control?token="+controllerToken, nil)
+	assert.Exactly(t, http.StatusOK, r4.Code)
+
+	// nothing changed by the rejected requests
+	assert.False(t, readFromStore(t, s, "abcde").Open)
 }
 
 func TestChoices(t *testing.T) {
@@ -55,14 +114,15 @@ func TestChoices(t *testing.T) {
 	assert.Exactly(t, http.StatusOK, r2.Code)
 	assert.JSONEq(t, `{
 			"Choices": ["alice", "bob", "carol"],
-			"Open": false
+			"Open": false,
+			"Item": null
 		}`,
 		r2.Body.String())
 }
 
 func TestVote(t *testing.T) {
 	s := store.NewInMemory()
-	e := event.New()
+	e := event.NewInMemory()
 	r := handler.New(s, e)
 
 	closed := sessionWithChoices("red", "blue")
@@ -70,6 +130,10 @@ func TestVote(t *testing.T) {
 
 	open := sessionWithChoices("red", "blue")
 	open.Participants = []string{"Alice", "Bob"}
+	open.ParticipantHashes = map[string]string{
+		"Alice": domain.HashToken("alice-token"),
+		"Bob":   domain.HashToken("bob-token"),
+	}
 	open.Open = true
 	insertToStore(t, s, "open", open)
 
@@ -87,16 +151,23 @@ func TestVote(t *testing.T) {
 	assert.Exactly(t, http.StatusBadRequest, r3.Code)
 	assert.Exactly(t, "not a valid participant\n", r3.Body.String())
 
+	// voting without the participant's token returns 401
+	r4 := newRequest(t, r, "PUT", "/open", `{"Choice": "red", "Participant": "Alice"}`)
+	assert.Exactly(t, http.StatusUnauthorized, r4.Code)
+
+	r5 := newRequestWithToken(t, r, "PUT", "/open", "bob-token", `{"Choice": "red", "Participant": "Alice"}`)
+	assert.Exactly(t, http.StatusUnauthorized, r5.Code)
+
 	// voting to a nonexisting option returns 400
-	r4 := newRequest(t, r, "PUT", "/open", `{"Choice": "green", "Participant": "Alice"}`)
-	assert.Exactly(t, http.StatusBadRequest, r4.Code)
-	assert.Exactly(t, "not a valid choice\n", r4.Body.String())
+	r6 := newRequestWithToken(t, r, "PUT", "/open", "alice-token", `{"Choice": "green", "Participant": "Alice"}`)
+	assert.Exactly(t, http.StatusBadRequest, r6.Code)
+	assert.Exactly(t, "not a valid choice\n", r6.Body.String())
 
-	cEvents, vEvents := subscribe(t, e, "open", 3, 1)
+	cEvents, vEvents := subscribe(t, e, "open", 4, 1)
 
 	// successful vote, waiting for more
-	r5 := newRequest(t, r, "PUT", "/open", `{"Choice": "red", "Participant": "Alice"}`)
-	assert.Exactly(t, http.StatusAccepted, r5.Code)
+	r7 := newRequestWithToken(t, r, "PUT", "/open", "alice-token", `{"Choice": "red", "Participant": "Alice"}`)
+	assert.Exactly(t, http.StatusAccepted, r7.Code)
 
 	if current := readFromStore(t, s, "open"); assert.Contains(t, current.Votes, "Alice") {
 		assert.Exactly(t, "red", current.Votes["Alice"])
@@ -113,8 +184,8 @@ func TestVote(t *testing.T) {
 	}
 
 	// successful vote, last one
-	r6 := newRequest(t, r, "PUT", "/open", `{"Choice": "blue", "Participant": "Bob"}`)
-	assert.Exactly(t, http.StatusAccepted, r6.Code)
+	r8 := newRequestWithToken(t, r, "PUT", "/open", "bob-token", `{"Choice": "blue", "Participant": "Bob"}`)
+	assert.Exactly(t, http.StatusAccepted, r8.Code)
 
 	if current := readFromStore(t, s, "open"); assert.Contains(t, current.Votes, "Bob") {
 		assert.Exactly(t, "blue", current.Votes["Bob"])
@@ -138,6 +209,207 @@ func TestVote(t *testing.T) {
 	if got := <-cEvents; assert.NotNil(t, got) {
 		assert.Exactly(t, event.Disabled, got.Kind)
 	}
+	if got := <-cEvents; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Done, got.Kind)
+		if result := got.Data.(*handler.ResultData).Result; assert.NotNil(t, result) {
+			assert.Exactly(t, map[string]int{"red": 1, "blue": 1}, result.Histogram)
+			assert.False(t, result.Consensus)
+		}
+	}
+}
+
+func TestSitOut(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory()
+	r := handler.New(s, e)
+
+	sess := sessionWithChoices("1", "2")
+	sess.Participants = []string{"Alice", "Bob", "Carol"}
+	sess.ParticipantHashes = map[string]string{
+		"Alice": domain.HashToken("alice-token"),
+		"Bob":   domain.HashToken("bob-token"),
+		"Carol": domain.HashToken("carol-token"),
+	}
+	sess.Open = true
+	sess.Opened = time.Now()
+	insertToStore(t, s, "abcde", sess)
+
+	// abstaining counts as voting but not in the statistics
+	r1 := newRequestWithToken(t, r, "PUT", "/abcde", "alice-token", `{"Choice": "abstain", "Participant": "Alice"}`)
+	assert.Exactly(t, http.StatusAccepted, r1.Code)
+	r2 := newRequestWithToken(t, r, "PUT", "/abcde", "bob-token", `{"Choice": "2", "Participant": "Bob"}`)
+	assert.Exactly(t, http.StatusAccepted, r2.Code)
+	assert.True(t, readFromStore(t, s, "abcde").Open)
+
+	// only the participant or the controller can sit out
+	r3 := newRequestWithToken(t, r, "PATCH", "/abcde/sit-out", "bob-token", `{"Participant": "Carol", "SittingOut": true}`)
+	assert.Exactly(t, http.StatusUnauthorized, r3.Code)
+	r4 := newControlRequest(t, r, "PATCH", "/abcde/sit-out", `{"Participant": "Dave", "SittingOut": true}`)
+	assert.Exactly(t, http.StatusBadRequest, r4.Code)
+
+	// the round closes when the others have voted
+	controllerEvent, voterEvent := subscribe(t, e, "abcde", 3, 2)
+
+	r5 := newControlRequest(t, r, "PATCH", "/abcde/sit-out", `{"Participant": "Carol", "SittingOut": true}`)
+	assert.Exactly(t, http.StatusAccepted, r5.Code)
+
+	got := readFromStore(t, s, "abcde")
+	assert.False(t, got.Open)
+	assert.Exactly(t, []string{"Carol"}, got.SittingOut)
+	assert.Exactly(t, &domain.Statistics{Average: 2, Median: 2, Min: 2, Max: 2}, got.Result().Statistics)
+
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.ParticipantsChange, got.Kind)
+		assert.Exactly(t, []string{"Carol"}, got.Data.(*handler.ParticipantsChangedData).SittingOut)
+	}
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Disabled, got.Kind)
+	}
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Done, got.Kind)
+	}
+	if got := <-voterEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Disabled, got.Kind)
+	}
+
+	// coming back by themselves
+	r6 := newRequestWithToken(t, r, "PATCH", "/abcde/sit-out", "carol-token", `{"Participant": "Carol", "SittingOut": false}`)
+	assert.Exactly(t, http.StatusAccepted, r6.Code)
+	assert.Empty(t, readFromStore(t, s, "abcde").SittingOut)
+}
+
+func TestRetractVote(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory()
+	r := handler.New(s, e)
+
+	sess := sessionWithChoices("1", "2")
+	sess.Participants = []string{"Alice", "Bob"}
+	sess.ParticipantHashes = map[string]string{
+		"Alice": domain.HashToken("alice-token"),
+		"Bob":   domain.HashToken("bob-token"),
+	}
+	sess.Votes = map[string]string{"Alice": "1"}
+	sess.Open = true
+	insertToStore(t, s, "abcde", sess)
+
+	// only participants can retract
+	r1 := newRequest(t, r, "DELETE", "/abcde/vote", nil)
+	assert.Exactly(t, http.StatusUnauthorized, r1.Code)
+
+	controllerEvent, _ := subscribe(t, e, "abcde", 1, 0)
+
+	r2 := newRequestWithToken(t, r, "DELETE", "/abcde/vote", "alice-token", nil)
+	assert.Exactly(t, http.StatusNoContent, r2.Code)
+	assert.Empty(t, readFromStore(t, s, "abcde").Votes)
+
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Vote, got.Kind)
+		assert.Exactly(t, &handler.VotesChangedData{Votes: map[string]string{}}, got.Data)
+	}
+
+	// not after the round is closed
+	r3 := newControlRequest(t, r, "PATCH", "/abcde/control/stop", nil)
+	require.Exactly(t, http.StatusAccepted, r3.Code)
+	r4 := newRequestWithToken(t, r, "DELETE", "/abcde/vote", "alice-token", nil)
+	assert.Exactly(t, http.StatusBadRequest, r4.Code)
+}
+
+func TestGracePeriod(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory()
+	r := handler.New(s, e)
+
+	sess := sessionWithChoices("1", "2")
+	sess.Participants = []string{"Alice", "Bob"}
+	sess.ParticipantHashes = map[string]string{
+		"Alice": domain.HashToken("alice-token"),
+		"Bob":   domain.HashToken("bob-token"),
+	}
+	sess.Settings = domain.Settings{Grace: "200ms", HideChoices: true}
+	sess.Votes = map[string]string{"Alice": "1"}
+	sess.Open = true
+	sess.Opened = time.Now()
+	insertToStore(t, s, "abcde", sess)
+
+	controllerEvent, voterEvent := subscribe(t, e, "abcde", 6, 3)
+
+	// the round is not closed right away when everybody voted
+	r1 := newRequestWithToken(t, r, "PUT", "/abcde", "bob-token", `{"Choice": "2", "Participant": "Bob"}`)
+	require.Exactly(t, http.StatusAccepted, r1.Code)
+
+	got := readFromStore(t, s, "abcde")
+	assert.True(t, got.Open)
+	assert.False(t, got.Closing.IsZero())
+
+	// controllers see only who voted while the choices are hidden
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Vote, got.Kind)
+		assert.Exactly(t, &handler.VotesChangedData{Voted: []string{"Alice", "Bob"}}, got.Data)
+	}
+
+	// everybody is told when it closes
+	if got := <-voterEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Enabled, got.Kind)
+		assert.NotNil(t, got.Data.(*handler.OpenChangedData).Deadline)
+	}
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Enabled, got.Kind)
+	}
+
+	// votes can still be changed
+	r2 := newRequestWithToken(t, r, "PUT", "/abcde", "bob-token", `{"Choice": "1", "Participant": "Bob"}`)
+	require.Exactly(t, http.StatusAccepted, r2.Code)
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Vote, got.Kind)
+	}
+
+	// and closed after the grace period
+	if got := <-voterEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Disabled, got.Kind)
+	}
+	if got := <-voterEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Done, got.Kind)
+		assert.True(t, got.Data.(*handler.RevealedData).Result.Consensus)
+	}
+	assert.False(t, readFromStore(t, s, "abcde").Open)
+
+	// revealing the votes to the controllers
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Disabled, got.Kind)
+	}
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Vote, got.Kind)
+		assert.Exactly(t, &handler.VotesChangedData{
+			Votes: map[string]string{"Alice": "1", "Bob": "1"},
+		}, got.Data)
+	}
+}
+
+func TestGracePeriod_Retract(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory()
+	r := handler.New(s, e)
+
+	sess := sessionWithChoices("1", "2")
+	sess.Participants = []string{"Alice"}
+	sess.ParticipantHashes = map[string]string{"Alice": domain.HashToken("alice-token")}
+	sess.Settings = domain.Settings{Grace: "100ms"}
+	sess.Open = true
+	sess.Opened = time.Now()
+	insertToStore(t, s, "abcde", sess)
+
+	r1 := newRequestWithToken(t, r, "PUT", "/abcde", "alice-token", `{"Choice": "2", "Participant": "Alice"}`)
+	require.Exactly(t, http.StatusAccepted, r1.Code)
+	assert.False(t, readFromStore(t, s, "abcde").Closing.IsZero())
+
+	// retracting calls off the closing
+	r2 := newRequestWithToken(t, r, "DELETE", "/abcde/vote", "alice-token", nil)
+	require.Exactly(t, http.StatusNoContent, r2.Code)
+	assert.True(t, readFromStore(t, s, "abcde").Closing.IsZero())
+
+	time.Sleep(200 * time.Millisecond)
+	assert.True(t, readFromStore(t, s, "abcde").Open)
 }
 
 func TestGetSession(t *testing.T) {
@@ -145,42 +417,54 @@ func TestGetSession(t *testing.T) {
 	r := handler.New(s, nil)
 
 	// returns 404 when no id is in store
-	r1 := newRequest(t, r, "GET", "/abcde/control", nil)
+	r1 := newControlRequest(t, r, "GET", "/abcde/control", nil)
 	assert.Exactly(t, http.StatusNotFound, r1.Code)
 
 	// successful request
 	insertToStore(t, s, "abcde", sessionWithChoices("yes", "no"))
 
-	r2 := newRequest(t, r, "GET", "/abcde/control", nil)
+	r2 := newControlRequest(t, r, "GET", "/abcde/control", nil)
 	assert.Exactly(t, http.StatusOK, r2.Code)
 	assert.JSONEq(t, `{
 			"Choices": ["yes", "no"],
 			"Participants": [],
 			"Votes": {},
+			"Presence": null,
+			"Observers": [],
+			"Settings": {
+				"HideNames": false,
+				"Anonymous": false,
+				"AutoClose": "",
+				"Quorum": 0,
+				"Timer": "",
+				"Grace": "",
+				"HideChoices": false
+			},
 			"Open": false
 		}`, r2.Body.String())
 }
 
 func TestStartVote(t *testing.T) {
 	s := store.NewInMemory()
-	e := event.New()
+	e := event.NewInMemory()
 	r := handler.New(s, e)
 
 	// returns 404 when no id is in store
-	r1 := newRequest(t, r, "PATCH", "/bcdef/control/start", nil)
+	r1 := newControlRequest(t, r, "PATCH", "/bcdef/control/start", nil)
 	assert.Exactly(t, http.StatusNotFound, r1.Code)
 
 	// successful request
 	insertToStore(t, s, "bcdef", &domain.Session{
-		Choices:      []string{"dog", "cat"},
-		Open:         false,
-		Votes:        map[string]string{"Alice": "dog"},
-		Participants: []string{"Alice"},
+		Choices:        []string{"dog", "cat"},
+		Open:           false,
+		Votes:          map[string]string{"Alice": "dog"},
+		Participants:   []string{"Alice"},
+		ControllerHash: domain.HashToken(controllerToken),
 	})
 
 	controllerEvent, voterEvent := subscribe(t, e, "bcdef", 1, 1)
 
-	r2 := newRequest(t, r, "PATCH", "/bcdef/control/start", nil)
+	r2 := newControlRequest(t, r, "PATCH", "/bcdef/control/start", nil)
 	assert.Exactly(t, http.StatusAccepted, r2.Code)
 
 	sess := readFromStore(t, s, "bcdef")
@@ -194,26 +478,99 @@ func TestStartVote(t *testing.T) {
 	}
 }
 
+func TestDeadline(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory()
+	r := handler.New(s, e)
+
+	insertToStore(t, s, "bcdef", &domain.Session{
+		Choices:        []string{"dog", "cat"},
+		Votes:          map[string]string{},
+		Participants:   []string{"Alice"},
+		ControllerHash: domain.HashToken(controllerToken),
+	})
+
+	// invalid duration returns 400
+	r1 := newControlRequest(t, r, "PATCH", "/bcdef/control/start", `{"Duration": "soon"}`)
+	assert.Exactly(t, http.StatusBadRequest, r1.Code)
+
+	// the round closes when the deadline passes
+	_, voterEvent := subscribe(t, e, "bcdef", 0, 3)
+
+	r2 := newControlRequest(t, r, "PATCH", "/bcdef/control/start", `{"Duration": "100ms"}`)
+	assert.Exactly(t, http.StatusAccepted, r2.Code)
+
+	deadline := readFromStore(t, s, "bcdef").Deadline
+	assert.False(t, deadline.IsZero())
+	if got := <-voterEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Enabled, got.Kind)
+		assert.True(t, deadline.Equal(*got.Data.(*handler.OpenChangedData).Deadline))
+	}
+	if got := <-voterEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Disabled, got.Kind)
+	}
+	if got := <-voterEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Done, got.Kind)
+	}
+	assert.False(t, readFromStore(t, s, "bcdef").Open)
+
+	// without a timer running the expired round is closed on read
+	insertToStore(t, s, "cdefg", &domain.Session{
+		Choices:      []string{"dog", "cat"},
+		Open:         true,
+		Votes:        map[string]string{},
+		Participants: []string{"Alice"},
+		ParticipantHashes: map[string]string{
+			"Alice": domain.HashToken("alice-token"),
+		},
+		Opened:   time.Now().Add(-time.Minute),
+		Deadline: time.Now().Add(-time.Second),
+	})
+
+	r3 := newRequest(t, r, "GET", "/cdefg", nil)
+	assert.Exactly(t, http.StatusOK, r3.Code)
+	assert.JSONEq(t, `{"Choices": ["dog", "cat"], "Open": false, "Item": null}`, r3.Body.String())
+	assert.False(t, readFromStore(t, s, "cdefg").Closed.IsZero())
+
+	// and on vote
+	insertToStore(t, s, "defgh", &domain.Session{
+		Choices:      []string{"dog", "cat"},
+		Open:         true,
+		Votes:        map[string]string{},
+		Participants: []string{"Alice"},
+		ParticipantHashes: map[string]string{
+			"Alice": domain.HashToken("alice-token"),
+		},
+		Opened:   time.Now().Add(-time.Minute),
+		Deadline: time.Now().Add(-time.Second),
+	})
+
+	r4 := newRequestWithToken(t, r, "PUT", "/defgh", "alice-token", `{"Choice": "dog", "Participant": "Alice"}`)
+	assert.Exactly(t, http.StatusBadRequest, r4.Code)
+	assert.False(t, readFromStore(t, s, "defgh").Open)
+}
+
 func TestStopVote(t *testing.T) {
 	s := store.NewInMemory()
-	e := event.New()
+	e := event.NewInMemory()
 	r := handler.New(s, e)
 
 	// returns 404 when no id is in store
-	r1 := newRequest(t, r, "PATCH", "/bcdef/control/stop", nil)
+	r1 := newControlRequest(t, r, "PATCH", "/bcdef/control/stop", nil)
 	assert.Exactly(t, http.StatusNotFound, r1.Code)
 
 	// successful request
 	insertToStore(t, s, "bcdef", &domain.Session{
-		Choices:      []string{"dog", "cat"},
-		Open:         true,
-		Votes:        map[string]string{"Alice": "dog"},
-		Participants: []string{"Alice"},
+		Choices:        []string{"dog", "cat"},
+		Open:           true,
+		Votes:          map[string]string{"Alice": "dog"},
+		Participants:   []string{"Alice"},
+		ControllerHash: domain.HashToken(controllerToken),
 	})
 
-	controllerEvent, voterEvent := subscribe(t, e, "bcdef", 1, 1)
+	controllerEvent, voterEvent := subscribe(t, e, "bcdef", 2, 2)
 
-	r2 := newRequest(t, r, "PATCH", "/bcdef/control/stop", nil)
+	r2 := newControlRequest(t, r, "PATCH", "/bcdef/control/stop", nil)
 	assert.Exactly(t, http.StatusAccepted, r2.Code)
 
 	sess := readFromStore(t, s, "bcdef")
@@ -221,30 +578,77 @@ func TestStopVote(t *testing.T) {
 	if got := <-voterEvent; assert.NotNil(t, got) {
 		assert.Exactly(t, event.Disabled, got.Kind)
 	}
+	if got := <-voterEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Done, got.Kind)
+		assert.Exactly(t, map[string]string{"Alice": "dog"}, got.Data.(*handler.RevealedData).Votes)
+	}
 	if got := <-controllerEvent; assert.NotNil(t, got) {
 		assert.Exactly(t, event.Disabled, got.Kind)
 	}
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Done, got.Kind)
+		assert.True(t, got.Data.(*handler.ResultData).Result.Consensus)
+	}
+}
+
+func TestResult(t *testing.T) {
+	s := store.NewInMemory()
+	r := handler.New(s, nil)
+
+	// returns 404 when no id is in store
+	r1 := newControlRequest(t, r, "GET", "/bcdef/control/result", nil)
+	assert.Exactly(t, http.StatusNotFound, r1.Code)
+
+	// successful request
+	sess := sessionWithChoices("1", "2", "3", "coffee")
+	sess.Participants = []string{"Alice", "Bob", "Carol"}
+	sess.Votes = map[string]string{"Alice": "1", "Bob": "3", "Carol": "coffee"}
+	insertToStore(t, s, "bcdef", sess)
+
+	r2 := newControlRequest(t, r, "GET", "/bcdef/control/result", nil)
+	assert.Exactly(t, http.StatusOK, r2.Code)
+	assert.JSONEq(t, `{
+			"Histogram": {"1": 1, "2": 0, "3": 1, "coffee": 1},
+			"Mode": ["1", "3", "coffee"],
+			"Consensus": false,
+			"Statistics": {
+				"Average": 2,
+				"Median": 2,
+				"Min": 1,
+				"Max": 3,
+				"Spread": 2
+			}
+		}`, r2.Body.String())
+
+	// refused while the choices are hidden
+	sess.Settings.HideChoices = true
+	sess.Open = true
+	insertToStore(t, s, "cdefg", sess)
+
+	r3 := newControlRequest(t, r, "GET", "/cdefg/control/result", nil)
+	assert.Exactly(t, http.StatusConflict, r3.Code)
 }
 
 func TestResetVote(t *testing.T) {
 	s := store.NewInMemory()
-	e := event.New()
+	e := event.NewInMemory()
 	r := handler.New(s, e)
 
 	// returns 404 when no id is in store
-	r1 := newRequest(t, r, "PATCH", "/bcdef/control/reset", nil)
+	r1 := newControlRequest(t, r, "PATCH", "/bcdef/control/reset", nil)
 	assert.Exactly(t, http.StatusNotFound, r1.Code)
 
 	// successful request
 	insertToStore(t, s, "bcdef", &domain.Session{
-		Choices:      []string{"dog", "cat"},
-		Open:         true,
-		Votes:        map[string]string{"Alice": "dog"},
-		Participants: []string{"Alice"},
+		Choices:        []string{"dog", "cat"},
+		Open:           true,
+		Votes:          map[string]string{"Alice": "dog"},
+		Participants:   []string{"Alice"},
+		ControllerHash: domain.HashToken(controllerToken),
 	})
 	controllerEvent, voterEvent := subscribe(t, e, "bcdef", 1, 2)
 
-	r2 := newRequest(t, r, "PATCH", "/bcdef/control/reset", nil)
+	r2 := newControlRequest(t, r, "PATCH", "/bcdef/control/reset", nil)
 	assert.Exactly(t, http.StatusAccepted, r2.Code)
 
 	sess := readFromStore(t, s, "bcdef")
@@ -264,57 +668,629 @@ func TestResetVote(t *testing.T) {
 
 func TestKickParticipant(t *testing.T) {
 	s := store.NewInMemory()
-	e := event.New()
+	e := event.NewInMemory()
 	r := handler.New(s, e)
 
 	// returns 404 when no id is in store
-	r1 := newRequest(t, r, "PATCH", "/bcdef/control/kick", `Bob`)
+	r1 := newControlRequest(t, r, "PATCH", "/bcdef/control/kick", `Bob`)
 	assert.Exactly(t, http.StatusNotFound, r1.Code)
 
 	// successful request
 	insertToStore(t, s, "bcdef", &domain.Session{
-		Choices:      []string{"square", "circle", "triangle"},
-		Open:         false,
-		Votes:        map[string]string{},
-		Participants: []string{"Alice", "Bob"},
+		Choices:        []string{"square", "circle", "triangle"},
+		Open:           false,
+		Votes:          map[string]string{},
+		Participants:   []string{"Alice", "Bob"},
+		ControllerHash: domain.HashToken(controllerToken),
+		ParticipantHashes: map[string]string{
+			"Alice": domain.HashToken("alice-token"),
+			"Bob":   domain.HashToken("bob-token"),
+		},
 	})
 	controllerEvent, _ := subscribe(t, e, "bcdef", 1, 0)
 
-	r2 := newRequest(t, r, "PATCH", "/bcdef/control/kick", `Bob`)
+	r2 := newControlRequest(t, r, "PATCH", "/bcdef/control/kick", `Bob`)
 	assert.Exactly(t, http.StatusNoContent, r2.Code)
 
 	sess := readFromStore(t, s, "bcdef")
 	assert.NotContains(t, sess.Participants, "Bob")
+	assert.NotContains(t, sess.ParticipantHashes, "Bob")
 	if got := <-controllerEvent; assert.NotNil(t, got) {
 		assert.Exactly(t, event.ParticipantsChange, got.Kind)
 		assert.Exactly(t, sess.Participants, got.Data.(*handler.ParticipantsChangedData).Participants)
 	}
+
+	// the vote of the kicked participant is dropped
+	insertToStore(t, s, "cdefg", &domain.Session{
+		Choices:        []string{"square", "circle", "triangle"},
+		Open:           true,
+		Opened:         time.Now(),
+		Votes:          map[string]string{"Alice": "square", "Bob": "circle"},
+		Participants:   []string{"Alice", "Bob", "Carol"},
+		ControllerHash: domain.HashToken(controllerToken),
+	})
+
+	r3 := newControlRequest(t, r, "PATCH", "/cdefg/control/kick", `Bob`)
+	assert.Exactly(t, http.StatusNoContent, r3.Code)
+	sess = readFromStore(t, s, "cdefg")
+	assert.Exactly(t, map[string]string{"Alice": "square"}, sess.Votes)
+	assert.True(t, sess.Open)
+
+	// and the round closes when the rest has voted
+	_, voterEvent := subscribe(t, e, "cdefg", 0, 2)
+
+	r4 := newControlRequest(t, r, "PATCH", "/cdefg/control/kick", `Carol`)
+	assert.Exactly(t, http.StatusNoContent, r4.Code)
+	assert.False(t, readFromStore(t, s, "cdefg").Open)
+	for _, want := range []event.Type{event.Disabled, event.Done} {
+		if got := <-voterEvent; assert.NotNil(t, got) {
+			assert.Exactly(t, want, got.Kind)
+		}
+	}
+}
+
+func TestSettings(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory()
+	r := handler.New(s, e)
+
+	// returns 404 when no id is in store
+	r1 := newControlRequest(t, r, "PATCH", "/abcde/control/settings", `{"HideNames": true}`)
+	assert.Exactly(t, http.StatusNotFound, r1.Code)
+
+	insertToStore(t, s, "abcde", &domain.Session{
+		Choices:        []string{"dog", "cat"},
+		Open:           true,
+		Votes:          map[string]string{"Alice": "dog"},
+		Participants:   []string{"Alice", "Bob"},
+		ControllerHash: domain.HashToken(controllerToken),
+	})
+
+	// successful request
+	r2 := newControlRequest(t, r, "PATCH", "/abcde/control/settings", `{"HideNames": true}`)
+	assert.Exactly(t, http.StatusAccepted, r2.Code)
+	assert.JSONEq(t, `{
+		"HideNames": true,
+		"Anonymous": false,
+		"AutoClose": "",
+		"Quorum": 0,
+		"Timer": "",
+		"Grace": "",
+		"HideChoices": false
+	}`, r2.Body.String())
+	assert.True(t, readFromStore(t, s, "abcde").Settings.HideNames)
+
+	// missing settings are kept
+	r3 := newControlRequest(t, r, "PATCH", "/abcde/control/settings", `{}`)
+	assert.Exactly(t, http.StatusAccepted, r3.Code)
+	assert.True(t, readFromStore(t, s, "abcde").Settings.HideNames)
+
+	// voters get only the result when the names are hidden
+	_, voterEvent := subscribe(t, e, "abcde", 0, 2)
+
+	r4 := newControlRequest(t, r, "PATCH", "/abcde/control/stop", nil)
+	assert.Exactly(t, http.StatusAccepted, r4.Code)
+
+	if got := <-voterEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Disabled, got.Kind)
+	}
+	if got := <-voterEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Done, got.Kind)
+		revealed := got.Data.(*handler.RevealedData)
+		assert.Nil(t, revealed.Votes)
+		assert.Exactly(t, map[string]int{"dog": 1, "cat": 0}, revealed.Result.Histogram)
+	}
+}
+
+func TestAnonymous(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory()
+	r := handler.New(s, e)
+
+	sess := sessionWithChoices("1", "2", "3")
+	sess.Participants = []string{"Alice", "Bob", "Carol"}
+	sess.ParticipantHashes = map[string]string{
+		"Alice": domain.HashToken("alice-token"),
+		"Bob":   domain.HashToken("bob-token"),
+		"Carol": domain.HashToken("carol-token"),
+	}
+	insertToStore(t, s, "abcde", sess)
+
+	r1 := newControlRequest(t, r, "PATCH", "/abcde/control/settings", `{"Anonymous": true}`)
+	require.Exactly(t, http.StatusAccepted, r1.Code)
+	r2 := newControlRequest(t, r, "PATCH", "/abcde/control/start", nil)
+	require.Exactly(t, http.StatusAccepted, r2.Code)
+
+	controllerEvent, _ := subscribe(t, e, "abcde", 1, 0)
+
+	// votes are kept by a hash derived from the token, not the stored one
+	r3 := newRequestWithToken(t, r, "PUT", "/abcde", "alice-token", `{"Choice": "2", "Participant": "Alice"}`)
+	require.Exactly(t, http.StatusAccepted, r3.Code)
+	assert.Exactly(t,
+		map[string]string{domain.HashToken("vote:alice-token"): "2"},
+		readFromStore(t, s, "abcde").Votes)
+	assert.NotContains(t, readFromStore(t, s, "abcde").Votes, domain.HashToken("alice-token"))
+
+	// controllers get only the counts
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Vote, got.Kind)
+		assert.Exactly(t, &handler.VotesChangedData{
+			Counts: map[string]int{"1": 0, "2": 1, "3": 0},
+		}, got.Data)
+	}
+
+	r4 := newControlRequest(t, r, "GET", "/abcde/control", nil)
+	require.Exactly(t, http.StatusOK, r4.Code)
+	var res handler.SessionResponse
+	require.NoError(t, json.Unmarshal(r4.Body.Bytes(), &res))
+	assert.Nil(t, res.Votes)
+	assert.Exactly(t, map[string]int{"1": 0, "2": 1, "3": 0}, res.Counts)
+
+	// cannot be changed once votes are cast
+	r5 := newControlRequest(t, r, "PATCH", "/abcde/control/settings", `{"Anonymous": false}`)
+	assert.Exactly(t, http.StatusConflict, r5.Code)
+	assert.True(t, readFromStore(t, s, "abcde").Settings.Anonymous)
+
+	// the vote can be changed and retracted with the token
+	r8 := newRequestWithToken(t, r, "PUT", "/abcde", "alice-token", `{"Choice": "3", "Participant": "Alice"}`)
+	require.Exactly(t, http.StatusAccepted, r8.Code)
+	assert.Exactly(t,
+		map[string]string{domain.HashToken("vote:alice-token"): "3"},
+		readFromStore(t, s, "abcde").Votes)
+
+	r9 := newRequestWithToken(t, r, "DELETE", "/abcde/vote", "alice-token", nil)
+	require.Exactly(t, http.StatusNoContent, r9.Code)
+	sess = readFromStore(t, s, "abcde")
+	assert.Empty(t, sess.Votes)
+	assert.Empty(t, sess.Voted())
+
+	r10 := newRequestWithToken(t, r, "PUT", "/abcde", "alice-token", `{"Choice": "2", "Participant": "Alice"}`)
+	require.Exactly(t, http.StatusAccepted, r10.Code)
+	assert.Exactly(t, []string{"Alice"}, readFromStore(t, s, "abcde").Voted())
+
+	// archived rounds have only the counts
+	r6 := newRequestWithToken(t, r, "PUT", "/abcde", "bob-token", `{"Choice": "2", "Participant": "Bob"}`)
+	require.Exactly(t, http.StatusAccepted, r6.Code)
+	r7 := newControlRequest(t, r, "PATCH", "/abcde/control/start", nil)
+	require.Exactly(t, http.StatusAccepted, r7.Code)
+
+	if rounds := readFromStore(t, s, "abcde").Rounds; assert.Len(t, rounds, 1) {
+		assert.Nil(t, rounds[0].Votes)
+		assert.Exactly(t, map[string]int{"1": 0, "2": 2, "3": 0}, rounds[0].Counts)
+	}
+
+	// can be changed after the round is closed
+	r11 := newRequestWithToken(t, r, "PUT", "/abcde", "alice-token", `{"Choice": "1", "Participant": "Alice"}`)
+	require.Exactly(t, http.StatusAccepted, r11.Code)
+	r12 := newControlRequest(t, r, "PATCH", "/abcde/control/stop", nil)
+	require.Exactly(t, http.StatusAccepted, r12.Code)
+
+	r13 := newControlRequest(t, r, "PATCH", "/abcde/control/settings", `{"Anonymous": false}`)
+	assert.Exactly(t, http.StatusAccepted, r13.Code)
+	sess = readFromStore(t, s, "abcde")
+	assert.False(t, sess.Settings.Anonymous)
+	assert.Empty(t, sess.Votes)
+	if assert.Len(t, sess.Rounds, 2) {
+		assert.Exactly(t, map[string]int{"1": 1, "2": 0, "3": 0}, sess.Rounds[1].Counts)
+	}
+}
+
+func TestAutoClose(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory()
+	h := handler.New(s, e)
+	server := httptest.NewServer(h)
+	defer server.Close()
+	baseUrl := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	sess := sessionWithChoices("1", "2")
+	sess.Participants = []string{"Alice", "Bob"}
+	sess.ParticipantHashes = map[string]string{
+		"Alice": domain.HashToken("alice-token"),
+		"Bob":   domain.HashToken("bob-token"),
+	}
+	insertToStore(t, s, "abcde", sess)
+
+	// invalid settings return 400
+	r1 := newControlRequest(t, h, "PATCH", "/abcde/control/settings", `{"AutoClose": "quorum", "Quorum": 0}`)
+	assert.Exactly(t, http.StatusBadRequest, r1.Code)
+
+	// closing when the connected participants voted, Bob is not connected
+	r2 := newControlRequest(t, h, "PATCH", "/abcde/control/settings", `{"AutoClose": "connected"}`)
+	require.Exactly(t, http.StatusAccepted, r2.Code)
+	r3 := newControlRequest(t, h, "PATCH", "/abcde/control/start", nil)
+	require.Exactly(t, http.StatusAccepted, r3.Code)
+
+	ws, _, err := websocket.DefaultDialer.Dial(baseUrl+"/abcde/ws?token=alice-token", nil)
+	require.NoError(t, err)
+	defer ws.Close()
+	_, _, err = ws.ReadMessage() // snapshot
+	require.NoError(t, err)
+
+	r4 := newRequestWithToken(t, h, "PUT", "/abcde", "alice-token", `{"Choice": "1", "Participant": "Alice"}`)
+	require.Exactly(t, http.StatusAccepted, r4.Code)
+	assert.False(t, readFromStore(t, s, "abcde").Open)
+
+	// never closing
+	r5 := newControlRequest(t, h, "PATCH", "/abcde/control/settings", `{"AutoClose": "never"}`)
+	require.Exactly(t, http.StatusAccepted, r5.Code)
+	r6 := newControlRequest(t, h, "PATCH", "/abcde/control/start", nil)
+	require.Exactly(t, http.StatusAccepted, r6.Code)
+
+	for _, voter := range []string{"Alice", "Bob"} {
+		token := strings.ToLower(voter) + "-token"
+		rr := newRequestWithToken(t, h, "PUT", "/abcde", token, `{"Choice": "2", "Participant": "`+voter+`"}`)
+		require.Exactly(t, http.StatusAccepted, rr.Code)
+	}
+	assert.True(t, readFromStore(t, s, "abcde").Open)
+
+	// rounds get the deadline of the timer
+	r7 := newControlRequest(t, h, "PATCH", "/abcde/control/settings", `{"AutoClose": "timer", "Timer": "1h"}`)
+	require.Exactly(t, http.StatusAccepted, r7.Code)
+	r8 := newControlRequest(t, h, "PATCH", "/abcde/control/start", nil)
+	require.Exactly(t, http.StatusAccepted, r8.Code)
+
+	got := readFromStore(t, s, "abcde")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), got.Deadline, time.Minute)
+
+	// the rounds of the items too, unless a duration is given
+	r9 := newControlRequest(t, h, "POST", "/abcde/control/items", `{"Title": "login"}`)
+	require.Exactly(t, http.StatusCreated, r9.Code)
+	r10 := newControlRequest(t, h, "POST", "/abcde/control/items", `{"Title": "logout"}`)
+	require.Exactly(t, http.StatusCreated, r10.Code)
+
+	r11 := newControlRequest(t, h, "PATCH", "/abcde/control/next", nil)
+	require.Exactly(t, http.StatusAccepted, r11.Code)
+	got = readFromStore(t, s, "abcde")
+	assert.Exactly(t, "login", got.Topic)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), got.Deadline, time.Minute)
+
+	r12 := newControlRequest(t, h, "PATCH", "/abcde/control/next", `{"Duration": "100ms"}`)
+	require.Exactly(t, http.StatusAccepted, r12.Code)
+	assert.Exactly(t, "logout", readFromStore(t, s, "abcde").Topic)
+	assert.Eventually(t, func() bool {
+		return !readFromStore(t, s, "abcde").Open
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRounds(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory()
+	r := handler.New(s, e)
+
+	sess := sessionWithChoices("1", "2", "3")
+	sess.Participants = []string{"Alice"}
+	sess.ParticipantHashes = map[string]string{"Alice": domain.HashToken("alice-token")}
+	insertToStore(t, s, "round", sess)
+
+	// no rounds yet
+	r1 := newControlRequest(t, r, "GET", "/round/control/rounds", nil)
+	assert.Exactly(t, http.StatusOK, r1.Code)
+	assert.JSONEq(t, `{"Rounds": []}`, r1.Body.String())
+
+	// first round with a topic
+	r2 := newControlRequest(t, r, "PATCH", "/round/control/start", `{"Topic": "login page"}`)
+	assert.Exactly(t, http.StatusAccepted, r2.Code)
+	assert.Exactly(t, "login page", readFromStore(t, s, "round").Topic)
+
+	controllerEvent, _ := subscribe(t, e, "round", 5, 0)
+
+	// closing the round archives it
+	r3 := newRequestWithToken(t, r, "PUT", "/round", "alice-token", `{"Choice": "3", "Participant": "Alice"}`)
+	assert.Exactly(t, http.StatusAccepted, r3.Code)
+
+	for _, want := range []event.Type{event.Vote, event.Disabled, event.Done} {
+		if got := <-controllerEvent; assert.NotNil(t, got) {
+			assert.Exactly(t, want, got.Kind)
+		}
+	}
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.RoundArchived, got.Kind)
+		if data := got.Data.(*handler.RoundArchivedData); assert.Exactly(t, 0, data.Index) {
+			assert.Exactly(t, "login page", data.Round.Topic)
+			assert.Exactly(t, map[string]string{"Alice": "3"}, data.Round.Votes)
+		}
+	}
+	assert.Len(t, readFromStore(t, s, "round").Rounds, 1)
+
+	// starting the next round does not archive the closed one again
+	r4 := newControlRequest(t, r, "PATCH", "/round/control/start", nil)
+	assert.Exactly(t, http.StatusAccepted, r4.Code)
+
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Enabled, got.Kind)
+	}
+	assert.Len(t, readFromStore(t, s, "round").Rounds, 1)
+
+	// resetting archives the round too
+	r5 := newControlRequest(t, r, "PATCH", "/round/control/reset", nil)
+	assert.Exactly(t, http.StatusAccepted, r5.Code)
+
+	sess = readFromStore(t, s, "round")
+	if assert.Len(t, sess.Rounds, 2) {
+		first := sess.Rounds[0]
+		assert.Exactly(t, "login page", first.Topic)
+		assert.Exactly(t, []string{"1", "2", "3"}, first.Choices)
+		assert.Exactly(t, []string{"Alice"}, first.Participants)
+		assert.Exactly(t, map[string]string{"Alice": "3"}, first.Votes)
+		assert.False(t, first.Opened.IsZero())
+		assert.False(t, first.Closed.Before(first.Opened))
+
+		assert.Empty(t, sess.Rounds[1].Votes)
+	}
+	assert.Empty(t, sess.Topic)
+	assert.True(t, sess.Opened.IsZero())
+
+	// rounds are listed in order
+	r6 := newControlRequest(t, r, "GET", "/round/control/rounds", nil)
+	assert.Exactly(t, http.StatusOK, r6.Code)
+	var rounds handler.RoundsResponse
+	require.NoError(t, json.Unmarshal(r6.Body.Bytes(), &rounds))
+	assert.Len(t, rounds.Rounds, 2)
+
+	// a single round can be requested
+	r7 := newControlRequest(t, r, "GET", "/round/control/rounds/0", nil)
+	assert.Exactly(t, http.StatusOK, r7.Code)
+	var round domain.Round
+	require.NoError(t, json.Unmarshal(r7.Body.Bytes(), &round))
+	assert.Exactly(t, "login page", round.Topic)
+
+	// requesting a nonexisting round returns 404
+	r8 := newControlRequest(t, r, "GET", "/round/control/rounds/2", nil)
+	assert.Exactly(t, http.StatusNotFound, r8.Code)
+}
+
+func TestItems(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory()
+	r := handler.New(s, e)
+
+	insertToStore(t, s, "items", sessionWithChoices("1", "2", "3"))
+
+	// adding items
+	r1 := newControlRequest(t, r, "POST", "/items/control/items", `{"Title": "login"}`)
+	assert.Exactly(t, http.StatusCreated, r1.Code)
+	r2 := newControlRequest(t, r, "POST", "/items/control/items", `{"Title": "logout", "Link": "http://x/2"}`)
+	assert.Exactly(t, http.StatusCreated, r2.Code)
+	r3 := newControlRequest(t, r, "POST", "/items/control/items", `{"Title": "signup"}`)
+	assert.Exactly(t, http.StatusCreated, r3.Code)
+
+	// listing items
+	r4 := newControlRequest(t, r, "GET", "/items/control/items", nil)
+	assert.Exactly(t, http.StatusOK, r4.Code)
+	var items handler.ItemsResponse
+	require.NoError(t, json.Unmarshal(r4.Body.Bytes(), &items))
+	assert.Exactly(t, -1, items.Current)
+	assert.Len(t, items.Items, 3)
+
+	// reordering with an invalid order returns 400
+	r5 := newControlRequest(t, r, "PATCH", "/items/control/items/order", `[0, 0, 1]`)
+	assert.Exactly(t, http.StatusBadRequest, r5.Code)
+
+	r6 := newControlRequest(t, r, "PATCH", "/items/control/items/order", `[2, 0, 1]`)
+	assert.Exactly(t, http.StatusAccepted, r6.Code)
+
+	sess := readFromStore(t, s, "items")
+	assert.Exactly(t, "signup", sess.Items[0].Title)
+	assert.Exactly(t, "login", sess.Items[1].Title)
+	assert.Exactly(t, "logout", sess.Items[2].Title)
+
+	// removing an item
+	r7 := newControlRequest(t, r, "DELETE", "/items/control/items/0", nil)
+	assert.Exactly(t, http.StatusNoContent, r7.Code)
+	r8 := newControlRequest(t, r, "DELETE", "/items/control/items/5", nil)
+	assert.Exactly(t, http.StatusNotFound, r8.Code)
+
+	controllerEvent, voterEvent := subscribe(t, e, "items", 5, 4)
+
+	// moving to the first item starts a vote about it
+	r9 := newControlRequest(t, r, "PATCH", "/items/control/next", nil)
+	assert.Exactly(t, http.StatusAccepted, r9.Code)
+
+	sess = readFromStore(t, s, "items")
+	assert.Exactly(t, 0, sess.Current)
+	assert.True(t, sess.Open)
+	assert.Exactly(t, "login", sess.Topic)
+
+	if got := <-voterEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.CurrentItem, got.Kind)
+		assert.Exactly(t, "login", got.Data.(*handler.CurrentItemData).Item.Title)
+	}
+	if got := <-voterEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.Enabled, got.Kind)
+	}
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.CurrentItem, got.Kind)
+	}
+
+	// voters see the current item
+	r10 := newRequest(t, r, "GET", "/items", nil)
+	assert.Exactly(t, http.StatusOK, r10.Code)
+	var choices handler.ChoicesResponse
+	require.NoError(t, json.Unmarshal(r10.Body.Bytes(), &choices))
+	if assert.NotNil(t, choices.Item) {
+		assert.Exactly(t, "login", choices.Item.Title)
+	}
+
+	// moving on records the estimate of the previous item
+	r11 := newControlRequest(t, r, "PATCH", "/items/control/next", `{"Estimate": "3"}`)
+	assert.Exactly(t, http.StatusAccepted, r11.Code)
+
+	sess = readFromStore(t, s, "items")
+	assert.Exactly(t, 1, sess.Current)
+	assert.Exactly(t, "3", sess.Items[0].Estimate)
+	assert.Exactly(t, "logout", sess.Topic)
+	if assert.Len(t, sess.Rounds, 1) {
+		assert.Exactly(t, "login", sess.Rounds[0].Topic)
+	}
+
+	// updating an item
+	r12 := newControlRequest(t, r, "PUT", "/items/control/items/0", `{"Title": "login", "Estimate": "5"}`)
+	assert.Exactly(t, http.StatusAccepted, r12.Code)
+	assert.Exactly(t, "5", readFromStore(t, s, "items").Items[0].Estimate)
+
+	// there is no item after the last one
+	r13 := newControlRequest(t, r, "PATCH", "/items/control/next", nil)
+	assert.Exactly(t, http.StatusBadRequest, r13.Code)
+	assert.Exactly(t, 1, readFromStore(t, s, "items").Current)
+
+	// but the estimate of the last item is recorded
+	r14 := newControlRequest(t, r, "PATCH", "/items/control/next", `{"Estimate": "8"}`)
+	assert.Exactly(t, http.StatusBadRequest, r14.Code)
+	sess = readFromStore(t, s, "items")
+	assert.Exactly(t, 1, sess.Current)
+	assert.Exactly(t, "8", sess.Items[1].Estimate)
+	assert.Exactly(t, "logout", sess.Topic)
+}
+
+func TestItems_RemoveCurrent(t *testing.T) {
+	s := store.NewInMemory()
+	r := handler.New(s, event.NewInMemory())
+
+	sess := sessionWithChoices("1", "2")
+	sess.Items = []domain.Item{{Title: "A"}, {Title: "B"}, {Title: "C"}}
+	sess.Current = 1
+	sess.Open = true
+	sess.Topic = "B"
+	sess.Opened = time.Now()
+	insertToStore(t, s, "items", sess)
+
+	// the open round loses its topic
+	r1 := newControlRequest(t, r, "DELETE", "/items/control/items/1", nil)
+	assert.Exactly(t, http.StatusNoContent, r1.Code)
+	assert.Empty(t, readFromStore(t, s, "items").Topic)
+
+	// moving on continues with the item that followed
+	r2 := newControlRequest(t, r, "PATCH", "/items/control/next", nil)
+	assert.Exactly(t, http.StatusAccepted, r2.Code)
+
+	got := readFromStore(t, s, "items")
+	assert.Exactly(t, 1, got.Current)
+	assert.Exactly(t, "C", got.Topic)
+}
+
+func TestEditChoices(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory()
+	r := handler.New(s, e)
+
+	insertToStore(t, s, "abcde", &domain.Session{
+		Choices:        []string{"1", "2", "3"},
+		Deck:           []domain.Card{{Choice: "3", Label: "three", Value: 3, Counts: true}},
+		Open:           true,
+		Votes:          map[string]string{},
+		ControllerHash: domain.HashToken(controllerToken),
+	})
+
+	// rejected while the round is open
+	r1 := newControlRequest(t, r, "POST", "/abcde/control/choices", "5")
+	assert.Exactly(t, http.StatusConflict, r1.Code)
+
+	r2 := newControlRequest(t, r, "PATCH", "/abcde/control/stop", nil)
+	require.Exactly(t, http.StatusAccepted, r2.Code)
+
+	controllerEvent, voterEvent := subscribe(t, e, "abcde", 1, 1)
+
+	// adding
+	r3 := newControlRequest(t, r, "POST", "/abcde/control/choices", "5")
+	assert.Exactly(t, http.StatusCreated, r3.Code)
+	assert.Exactly(t, []string{"1", "2", "3", "5"}, readFromStore(t, s, "abcde").Choices)
+
+	want := &handler.ChoicesChangedData{
+		Choices: []string{"1", "2", "3", "5"},
+		Deck:    []domain.Card{{Choice: "3", Label: "three", Value: 3, Counts: true}},
+	}
+	if got := <-voterEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.ChoicesChange, got.Kind)
+		assert.Exactly(t, want, got.Data)
+	}
+	if got := <-controllerEvent; assert.NotNil(t, got) {
+		assert.Exactly(t, event.ChoicesChange, got.Kind)
+		assert.Exactly(t, want, got.Data)
+	}
+
+	// duplicates are rejected
+	r4 := newControlRequest(t, r, "POST", "/abcde/control/choices", "5")
+	assert.Exactly(t, http.StatusBadRequest, r4.Code)
+
+	// reordering
+	r5 := newControlRequest(t, r, "PATCH", "/abcde/control/choices/order", `[3, 2, 1, 0]`)
+	assert.Exactly(t, http.StatusAccepted, r5.Code)
+	assert.Exactly(t, []string{"5", "3", "2", "1"}, readFromStore(t, s, "abcde").Choices)
+
+	r6 := newControlRequest(t, r, "PATCH", "/abcde/control/choices/order", `[0, 0, 1, 2]`)
+	assert.Exactly(t, http.StatusBadRequest, r6.Code)
+
+	// removing takes the card too
+	r7 := newControlRequest(t, r, "DELETE", "/abcde/control/choices/1", nil)
+	assert.Exactly(t, http.StatusNoContent, r7.Code)
+	got := readFromStore(t, s, "abcde")
+	assert.Exactly(t, []string{"5", "2", "1"}, got.Choices)
+	assert.Empty(t, got.Deck)
+
+	r8 := newControlRequest(t, r, "DELETE", "/abcde/control/choices/3", nil)
+	assert.Exactly(t, http.StatusNotFound, r8.Code)
+
+	// replacing with a preset
+	r9 := newControlRequest(t, r, "PUT", "/abcde/control/choices?deck=t-shirt", nil)
+	assert.Exactly(t, http.StatusAccepted, r9.Code)
+	got = readFromStore(t, s, "abcde")
+	assert.Exactly(t, []string{"XS", "S", "M", "L", "XL", "XXL", "?", "coffee"}, got.Choices)
+	assert.Exactly(t, 3.0, got.Card("M").Value)
+
+	// replacing with cards
+	r11 := newControlRequest(t, r, "PUT", "/abcde/control/choices", `[{"Choice": "big", "Label": "Big", "Value": 8, "Counts": true}]`)
+	assert.Exactly(t, http.StatusAccepted, r11.Code)
+	got = readFromStore(t, s, "abcde")
+	assert.Exactly(t, []string{"big"}, got.Choices)
+	assert.Exactly(t, domain.Card{Choice: "big", Label: "Big", Value: 8, Counts: true}, got.Card("big"))
+
+	// replacing with the given ones
+	r10 := newControlRequest(t, r, "PUT", "/abcde/control/choices", `["yes", "no"]`)
+	assert.Exactly(t, http.StatusAccepted, r10.Code)
+	got = readFromStore(t, s, "abcde")
+	assert.Exactly(t, []string{"yes", "no"}, got.Choices)
+	assert.Empty(t, got.Deck)
 }
 
 func TestControlWS(t *testing.T) {
 	s := store.NewInMemory()
-	e := event.New()
+	e := event.NewInMemory()
 	server := httptest.NewServer(handler.New(s, e))
 	defer server.Close()
 	baseUrl := "ws" + strings.TrimPrefix(server.URL, "http")
 
-	insertToStore(t, s, "aaaaa", sessionWithChoices("morning", "evening"))
+	sess := sessionWithChoices("morning", "evening")
+	sess.Participants = []string{"Alice"}
+	sess.Votes = map[string]string{"Alice": "morning"}
+	sess.Open = true
+	insertToStore(t, s, "aaaaa", sess)
 
-	ws, _, err := websocket.DefaultDialer.Dial(baseUrl+"/aaaaa/control/ws", nil)
+	ws, _, err := websocket.DefaultDialer.Dial(baseUrl+"/aaaaa/control/ws?token="+controllerToken, nil)
 	require.NoError(t, err)
 	defer ws.Close()
 
+	// the state is sent first, with the votes
+	_, p, err := ws.ReadMessage()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Kind": "snapshot", "Data": {
+		"Choices": ["morning", "evening"],
+		"Open": true,
+		"Participants": ["Alice"],
+		"Votes": {"Alice": "morning"},
+		"Presence": {"Alice": "offline"}}}`, string(p))
+
 	e.Emit("aaaaa", event.Controller, event.Enabled, nil)
 	e.Emit("aaaaa", event.Voter, event.Disabled, nil)
 
-	_, p, err := ws.ReadMessage()
+	_, p, err = ws.ReadMessage()
 	assert.NoError(t, err)
-	assert.JSONEq(t, `{"Kind": "enabled", "Data": null}`, string(p))
+	assert.JSONEq(t, `{"ID": 1, "Kind": "enabled", "Data": null}`, string(p))
 }
 
 func TestJoin(t *testing.T) {
 	s := store.NewInMemory()
-	e := event.New()
+	e := event.NewInMemory()
 	r := handler.New(s, e)
 
 	// requesting nonexisting session should return 404
@@ -336,6 +1312,12 @@ func TestJoin(t *testing.T) {
 	sess := readFromStore(t, s, "ididi")
 	assert.Contains(t, sess.Participants, "Alice")
 
+	// participant token is returned and only its hash is stored
+	var res handler.TokenResponse
+	require.NoError(t, json.Unmarshal(r2.Body.Bytes(), &res))
+	assert.NotEmpty(t, res.Token)
+	assert.True(t, sess.IsParticipant("Alice", res.Token))
+
 	if got := <-controllerEvent; assert.NotNil(t, got) {
 		assert.Exactly(t, event.ParticipantsChange, got.Kind)
 		assert.Exactly(t, sess.Participants, got.Data.(*handler.ParticipantsChangedData).Participants)
@@ -348,28 +1330,347 @@ func TestJoin(t *testing.T) {
 
 func TestWS(t *testing.T) {
 	s := store.NewInMemory()
-	e := event.New()
+	e := event.NewInMemory()
 	server := httptest.NewServer(handler.New(s, e))
 	defer server.Close()
 	baseUrl := "ws" + strings.TrimPrefix(server.URL, "http")
 
-	insertToStore(t, s, "aaaaa", sessionWithChoices("morning", "evening"))
+	sess := sessionWithChoices("morning", "evening")
+	sess.Participants = []string{"Alice"}
+	sess.ParticipantHashes = map[string]string{"Alice": domain.HashToken("alice-token")}
+	insertToStore(t, s, "aaaaa", sess)
+
+	// connecting without a participant token fails
+	_, res, err := websocket.DefaultDialer.Dial(baseUrl+"/aaaaa/ws", nil)
+	if assert.Error(t, err) {
+		assert.Exactly(t, http.StatusUnauthorized, res.StatusCode)
+	}
 
-	ws, _, err := websocket.DefaultDialer.Dial(baseUrl+"/aaaaa/ws", nil)
+	ws, _, err := websocket.DefaultDialer.Dial(baseUrl+"/aaaaa/ws?token=alice-token", nil)
 	require.NoError(t, err)
 	defer ws.Close()
 
+	// the state is sent first, without the votes
+	_, p, err := ws.ReadMessage()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Kind": "snapshot", "Data": {
+		"Choices": ["morning", "evening"],
+		"Open": false,
+		"Participants": ["Alice"],
+		"Votes": null,
+		"Presence": null}}`, string(p))
+
+	// the first is the presence of Alice to the controllers
 	e.Emit("aaaaa", event.Controller, event.Enabled, nil)
 	e.Emit("aaaaa", event.Voter, event.Disabled, nil)
 
+	_, p, err = ws.ReadMessage()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"ID": 3, "Kind": "disabled", "Data": null}`, string(p))
+}
+
+func TestPresence(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory()
+	h := handler.New(s, e)
+	server := httptest.NewServer(h)
+	defer server.Close()
+	baseUrl := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	sess := sessionWithChoices("morning", "evening")
+	sess.Participants = []string{"Alice", "Bob"}
+	sess.ParticipantHashes = map[string]string{"Alice": domain.HashToken("alice-token")}
+	insertToStore(t, s, "aaaaa", sess)
+
+	controller, _, err := websocket.DefaultDialer.Dial(baseUrl+"/aaaaa/control/ws?token="+controllerToken, nil)
+	require.NoError(t, err)
+	defer controller.Close()
+	_, _, err = controller.ReadMessage() // snapshot
+	require.NoError(t, err)
+
+	assertPresence := func(alice event.PresenceState) {
+		_, p, err := controller.ReadMessage()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"Participant": "Alice", "State": "`+string(alice)+`"}`,
+			payloadData(t, p, event.Presence))
+
+		rr := newControlRequest(t, h, "GET", "/aaaaa/control", nil)
+		var got handler.SessionResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Exactly(t, map[string]event.PresenceState{"Alice": alice, "Bob": event.Offline}, got.Presence)
+	}
+
+	// connecting makes the participant online
+	ws, _, err := websocket.DefaultDialer.Dial(baseUrl+"/aaaaa/ws?token=alice-token", nil)
+	require.NoError(t, err)
+	assertPresence(event.Online)
+
+	// voters tell when they are idle
+	require.NoError(t, ws.WriteJSON(&handler.IdleMessage{Idle: true}))
+	assertPresence(event.Idle)
+	require.NoError(t, ws.WriteJSON(&handler.IdleMessage{Idle: false}))
+	assertPresence(event.Online)
+
+	// disconnecting makes them offline
+	ws.Close()
+	assertPresence(event.Offline)
+}
+
+// payloadData returns the data of the payload after checking its kind.
+func payloadData(t *testing.T, p []byte, kind event.Type) string {
+	var got struct {
+		Kind event.Type
+		Data json.RawMessage
+	}
+	require.NoError(t, json.Unmarshal(p, &got))
+	assert.Exactly(t, kind, got.Kind)
+	return string(got.Data)
+}
+
+func TestObserve(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory()
+	h := handler.New(s, e)
+	server := httptest.NewServer(h)
+	defer server.Close()
+	baseUrl := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	sess := sessionWithChoices("morning", "evening")
+	sess.Participants = []string{"Alice"}
+	sess.ParticipantHashes = map[string]string{"Alice": domain.HashToken("alice-token")}
+	sess.Open = true
+	insertToStore(t, s, "aaaaa", sess)
+
+	// joining as observer returns a token
+	rr := newRequest(t, h, "PUT", "/aaaaa/observe", "Olivia")
+	require.Exactly(t, http.StatusCreated, rr.Code)
+	var token handler.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &token))
+
+	got := readFromStore(t, s, "aaaaa")
+	assert.Exactly(t, []string{"Olivia"}, got.Observers)
+	assert.Exactly(t, []string{"Alice"}, got.Participants)
+	assert.Exactly(t, domain.HashToken(token.Token), got.ObserverHashes["Olivia"])
+
+	// the same name cannot join twice
+	rr = newRequest(t, h, "PUT", "/aaaaa/observe", "Olivia")
+	assert.Exactly(t, http.StatusConflict, rr.Code)
+
+	// only observers can connect
+	_, res, err := websocket.DefaultDialer.Dial(baseUrl+"/aaaaa/observe/ws?token=alice-token", nil)
+	if assert.Error(t, err) {
+		assert.Exactly(t, http.StatusUnauthorized, res.StatusCode)
+	}
+
+	ws, _, err := websocket.DefaultDialer.Dial(baseUrl+"/aaaaa/observe/ws?token="+token.Token, nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	// votes are hidden while the round is open
 	_, p, err := ws.ReadMessage()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"Choices": ["morning", "evening"],
+		"Open": true,
+		"Participants": ["Alice"],
+		"Votes": null,
+		"Presence": null}`, payloadData(t, p, event.Snapshot))
+
+	// observers do not block completion and get the votes revealed
+	rr = newRequestWithToken(t, h, "PUT", "/aaaaa", "alice-token", `{"Choice": "evening", "Participant": "Alice"}`)
+	require.Exactly(t, http.StatusAccepted, rr.Code)
+	assert.False(t, readFromStore(t, s, "aaaaa").Open)
+
+	_, p, err = ws.ReadMessage()
+	require.NoError(t, err)
+	payloadData(t, p, event.Disabled)
+
+	_, p, err = ws.ReadMessage()
+	require.NoError(t, err)
+	var revealed handler.RevealedData
+	require.NoError(t, json.Unmarshal([]byte(payloadData(t, p, event.Done)), &revealed))
+	assert.Exactly(t, map[string]string{"Alice": "evening"}, revealed.Votes)
+	assert.True(t, revealed.Result.Consensus)
+}
+
+func TestWS_Since(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory(event.WithReplaySize(3))
+	server := httptest.NewServer(handler.New(s, e))
+	defer server.Close()
+	baseUrl := "ws" + strings.TrimPrefix(server.URL, "http") + "/aaaaa/ws?token=alice-token"
+
+	sess := sessionWithChoices("morning", "evening")
+	sess.Participants = []string{"Alice"}
+	sess.ParticipantHashes = map[string]string{"Alice": domain.HashToken("alice-token")}
+	insertToStore(t, s, "aaaaa", sess)
+
+	e.Emit("aaaaa", event.Voter, event.Enabled, nil)
+	e.Emit("aaaaa", event.Voter, event.Disabled, nil)
+	e.Emit("aaaaa", event.Voter, event.Reset, nil)
+
+	// missed events are sent first
+	ws, _, err := websocket.DefaultDialer.Dial(baseUrl+"&since=1", nil)
+	require.NoError(t, err)
+
+	_, p, err := ws.ReadMessage()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"ID": 2, "Kind": "disabled", "Data": null}`, string(p))
+	_, p, err = ws.ReadMessage()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"ID": 3, "Kind": "reset", "Data": null}`, string(p))
+
+	// connecting made Alice online with id 4
+	e.Emit("aaaaa", event.Voter, event.Enabled, nil)
+	_, p, err = ws.ReadMessage()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"ID": 5, "Kind": "enabled", "Data": null}`, string(p))
+	ws.Close()
+
+	// resync is needed when they are not kept anymore
+	ws, _, err = websocket.DefaultDialer.Dial(baseUrl+"&since=1", nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	_, p, err = ws.ReadMessage()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Kind": "resync", "Data": null}`, string(p))
+	_, p, err = ws.ReadMessage()
 	assert.NoError(t, err)
-	assert.JSONEq(t, `{"Kind": "disabled", "Data": null}`, string(p))
+	assert.Contains(t, string(p), `"Kind":"snapshot"`)
+}
+
+func TestEvents(t *testing.T) {
+	s := store.NewInMemory()
+	e := event.NewInMemory()
+	h := handler.New(s, e)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	sess := sessionWithChoices("morning", "evening")
+	sess.Participants = []string{"Alice"}
+	sess.ParticipantHashes = map[string]string{"Alice": domain.HashToken("alice-token")}
+	insertToStore(t, s, "aaaaa", sess)
+
+	// connecting without a participant token fails
+	res, err := http.Get(server.URL + "/aaaaa/events")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Exactly(t, http.StatusUnauthorized, res.StatusCode)
+
+	// controllers need the controller token
+	res, err = http.Get(server.URL + "/aaaaa/control/events?token=alice-token")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Exactly(t, http.StatusUnauthorized, res.StatusCode)
+
+	voter := openEvents(t, server.URL+"/aaaaa/events?token=alice-token", "")
+	controller := openEvents(t, server.URL+"/aaaaa/control/events?token="+controllerToken, "")
+	defer controller.Body.Close()
+
+	voterEvents := bufio.NewReader(voter.Body)
+	controllerEvents := bufio.NewReader(controller.Body)
+
+	// the state is sent first
+	_, data := readEvent(t, voterEvents)
+	assert.Contains(t, data, `"Kind":"snapshot"`)
+	_, data = readEvent(t, controllerEvents)
+	assert.Contains(t, data, `"Kind":"snapshot"`)
+
+	newControlRequest(t, h, "PATCH", "/aaaaa/control/start", nil)
+
+	// the first is the presence of Alice
+	id, data := readEvent(t, voterEvents)
+	assert.Exactly(t, "2", id)
+	assert.JSONEq(t, `{"ID": 2, "Kind": "enabled", "Data": {"Open": true}}`, data)
+
+	_, data = readEvent(t, controllerEvents)
+	assert.JSONEq(t, `{"ID": 3, "Kind": "enabled", "Data": {"Open": true}}`, data)
+
+	// events missed while disconnected are sent on resume
+	voter.Body.Close()
+	newControlRequest(t, h, "PATCH", "/aaaaa/control/stop", nil)
+
+	voter = openEvents(t, server.URL+"/aaaaa/events?token=alice-token", id)
+	defer voter.Body.Close()
+
+	_, data = readEvent(t, bufio.NewReader(voter.Body))
+	assert.JSONEq(t, `{"Open": false}`, payloadData(t, []byte(data), event.Disabled))
+}
+
+func openEvents(t *testing.T, url string, lastEventID string) *http.Response {
+	req, err := http.NewRequest("GET", url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Exactly(t, http.StatusOK, res.StatusCode)
+	assert.Exactly(t, "text/event-stream", res.Header.Get("Content-Type"))
+	return res
+}
+
+// readEvent returns the id and data fields of the next event in the stream.
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	var id, data string
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && data != "":
+			return id, data
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+const controllerToken = "controller-secret"
+
+func TestJanitor(t *testing.T) {
+	s := store.NewInMemory(store.WithTTL(50 * time.Millisecond))
+	e := event.NewInMemory()
+
+	insertToStore(t, s, "expired", sessionWithChoices("1", "2"))
+
+	c, err := e.Subscribe("expired", event.Voter, "ws")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handler.Janitor(ctx, s, e, 10*time.Millisecond)
+
+	// subscribers are notified then disconnected
+	select {
+	case got := <-c:
+		if assert.NotNil(t, got) {
+			assert.Exactly(t, event.Expired, got.Kind)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no expired event")
+	}
+
+	select {
+	case _, ok := <-c:
+		assert.False(t, ok, "channel is not closed")
+	case <-time.After(time.Second):
+		t.Fatal("channel is not closed")
+	}
+
+	_, err = s.Load("expired")
+	assert.Exactly(t, store.ErrNotExists, err)
 }
 
 func sessionWithChoices(choices ...string) *domain.Session {
 	res := domain.NewSession()
 	res.Choices = choices
+	res.ControllerHash = domain.HashToken(controllerToken)
 	// res.Version = uuid.MustParse("beb86503-f69e-4a21-9f30-93ca121fee93")
 	return res
 }
@@ -385,6 +1686,14 @@ func readFromStore(t *testing.T, s store.Store, id string) *domain.Session {
 }
 
 func newRequest(t *testing.T, h http.Handler, method string, url string, body interface{}) *httptest.ResponseRecorder {
+	return newRequestWithToken(t, h, method, url, "", body)
+}
+
+func newControlRequest(t *testing.T, h http.Handler, method string, url string, body interface{}) *httptest.ResponseRecorder {
+	return newRequestWithToken(t, h, method, url, controllerToken, body)
+}
+
+func newRequestWithToken(t *testing.T, h http.Handler, method string, url string, token string, body interface{}) *httptest.ResponseRecorder {
 	var reqBody io.Reader
 	switch body.(type) {
 	case string:
@@ -397,6 +1706,9 @@ func newRequest(t *testing.T, h http.Handler, method string, url string, body in
 
 	req, err := http.NewRequest(method, url, reqBody)
 	assert.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	rr := httptest.NewRecorder()
 	h.ServeHTTP(rr, req)
@@ -404,7 +1716,7 @@ func newRequest(t *testing.T, h http.Handler, method string, url string, body in
 	return rr
 }
 
-func waitForEvent(t *testing.T, e *event.Event, done chan bool, id string, r event.Role, result chan *event.Payload) {
+func waitForEvent(t *testing.T, e event.Event, done chan bool, id string, r event.Role, result chan *event.Payload) {
 	c, err := e.Subscribe(id, r, id)
 	assert.NoError(t, err)
 
@@ -422,7 +1734,7 @@ func waitForEvent(t *testing.T, e *event.Event, done chan bool, id string, r eve
 	}
 }
 
-func subscribe(t *testing.T, e *event.Event, id string, expectedControllerEvents, expectedVoterEvents int) (chan *event.Payload, chan *event.Payload) {
+func subscribe(t *testing.T, e event.Event, id string, expectedControllerEvents, expectedVoterEvents int) (chan *event.Payload, chan *event.Payload) {
 	controllerEvent := make(chan *event.Payload, expectedControllerEvents)
 	voterEvent := make(chan *event.Payload, expectedVoterEvents)
 
@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akarasz/pajthy-backend/domain"
+	"github.com/akarasz/pajthy-backend/store"
+)
+
+type ItemsResponse struct {
+	Items   []domain.Item
+	Current int
+}
+
+type NextRequest struct {
+	Estimate string
+	Duration string
+}
+
+func (h *Handler) items(w http.ResponseWriter, r *http.Request) {
+	session := mux.Vars(r)["session"]
+
+	log.Printf("items %q", session)
+
+	s, err := h.store.Load(session)
+	if err != nil {
+		showStoreError(w, err)
+		return
+	}
+
+	if err := showJSON(w, newItemsResponse(s.Data)); err != nil {
+		return
+	}
+}
+
+func (h *Handler) addItem(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["session"]
+
+	var item domain.Item
+	if err := readContent(w, r, &item); err != nil {
+		return
+	}
+
+	log.Printf("add item %q %q", id, item.Title)
+
+	saved, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
+		s.Items = append(s.Items, item)
+
+		return s, nil
+	})
+
+	switch err {
+	case nil:
+		if err := showJSONWithStatus(w, http.StatusCreated, newItemsResponse(saved)); err != nil {
+			return
+		}
+	case store.ErrVersionMismatch:
+		showError(w, http.StatusInternalServerError, "locking error, try again later", nil)
+		return
+	default:
+		showStoreError(w, err)
+		return
+	}
+}
+
+func (h *Handler) updateItem(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["session"]
+	n, _ := strconv.Atoi(mux.Vars(r)["n"])
+
+	var item domain.Item
+	if err := readContent(w, r, &item); err != nil {
+		return
+	}
+
+	log.Printf("update item %q %d", id, n)
+
+	saved, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
+		if n >= len(s.Items) {
+			return nil, errInvalidItem
+		}
+		s.Items[n] = item
+
+		return s, nil
+	})
+
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusAccepted)
+	case errInvalidItem:
+		showError(w, http.StatusNotFound, "item not exists", nil)
+		return
+	case store.ErrVersionMismatch:
+		showError(w, http.StatusInternalServerError, "locking error, try again later", nil)
+		return
+	default:
+		showStoreError(w, err)
+		return
+	}
+
+	if n == saved.Current {
+		h.emitCurrentItem(id, saved.CurrentItem())
+	}
+}
+
+func (h *Handler) removeItem(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["session"]
+	n, _ := strconv.Atoi(mux.Vars(r)["n"])
+
+	log.Printf("remove item %q %d", id, n)
+
+	wasCurrent := false
+	_, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
+		if n >= len(s.Items) {
+			return nil, errInvalidItem
+		}
+		wasCurrent = n == s.Current
+		s.RemoveItem(n)
+		if wasCurrent && s.Open {
+			s.Topic = ""
+		}
+
+		return s, nil
+	})
+
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errInvalidItem:
+		showError(w, http.StatusNotFound, "item not exists", nil)
+		return
+	case store.ErrVersionMismatch:
+		showError(w, http.StatusInternalServerError, "locking error, try again later", nil)
+		return
+	default:
+		showStoreError(w, err)
+		return
+	}
+
+	if wasCurrent {
+		h.emitCurrentItem(id, nil)
+	}
+}
+
+func (h *Handler) reorderItems(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["session"]
+
+	var order []int
+	if err := readContent(w, r, &order); err != nil {
+		return
+	}
+
+	log.Printf("reorder items %q %v", id, order)
+
+	_, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
+		if err := s.ReorderItems(order); err != nil {
+			return nil, err
+		}
+
+		return s, nil
+	})
+
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusAccepted)
+	case domain.ErrInvalidOrder:
+		showError(w, http.StatusBadRequest, "not a valid order", nil)
+		return
+	case store.ErrVersionMismatch:
+		showError(w, http.StatusInternalServerError, "locking error, try again later", nil)
+		return
+	default:
+		showStoreError(w, err)
+		return
+	}
+}
+
+func (h *Handler) nextItem(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["session"]
+
+	var req NextRequest
+	if err := readOptionalContent(w, r, &req); err != nil {
+		return
+	}
+
+	duration, err := parseDuration(w, req.Duration)
+	if err != nil {
+		return
+	}
+
+	log.Printf("next item %q", id)
+
+	var archived *domain.Round
+	var last bool
+	saved, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
+		previous := s.CurrentItem()
+		if previous != nil && req.Estimate != "" {
+			previous.Estimate = req.Estimate
+		}
+
+		next, ok := s.NextItem()
+		last = !ok
+		if last {
+			// the estimate of the last item is kept even without a next one
+			if previous == nil || req.Estimate == "" {
+				return nil, errNoMoreItems
+			}
+			return s, nil
+		}
+
+		archived, _ = s.Start(time.Now(), next.Title, duration)
+
+		return s, nil
+	})
+
+	if err == nil && last {
+		h.emitCurrentItem(id, saved.CurrentItem())
+		err = errNoMoreItems
+	}
+
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusAccepted)
+	case errNoMoreItems:
+		showError(w, http.StatusBadRequest, "no more items", nil)
+		return
+	case store.ErrVersionMismatch:
+		showError(w, http.StatusInternalServerError, "locking error, try again later", nil)
+		return
+	default:
+		showStoreError(w, err)
+		return
+	}
+
+	if archived != nil {
+		h.emitRoundArchived(id, len(saved.Rounds)-1, archived)
+	}
+	h.emitCurrentItem(id, saved.CurrentItem())
+	h.emitVoteEnabled(id, saved)
+	h.scheduleDeadline(id, saved)
+}
+
+func newItemsResponse(s *domain.Session) *ItemsResponse {
+	items := s.Items
+	if items == nil {
+		items = []domain.Item{}
+	}
+
+	return &ItemsResponse{
+		Items:   items,
+		Current: s.Current,
+	}
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/akarasz/pajthy-backend/event"
+	"github.com/akarasz/pajthy-backend/store"
+)
+
+// Janitor periodically removes the expired sessions from the store and
+// disconnects everybody still subscribed to them, until the context is done.
+func Janitor(ctx context.Context, s store.Store, e event.Event, interval time.Duration) {
+	h := &Handler{
+		store: s,
+		event: e,
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			h.cleanup()
+		}
+	}
+}
+
+func (h *Handler) cleanup() {
+	if e, ok := h.store.(store.Expirer); ok {
+		if err := e.Expire(); err != nil {
+			log.Printf("expire: %v", err)
+		}
+	}
+
+	for _, id := range h.event.Sessions() {
+		if _, err := h.store.Load(id); err != store.ErrNotExists {
+			continue
+		}
+
+		log.Printf("session expired %q", id)
+		h.emitExpired(id)
+		h.event.Close(id)
+	}
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akarasz/pajthy-backend/domain"
+	"github.com/akarasz/pajthy-backend/event"
+	"github.com/akarasz/pajthy-backend/store"
+)
+
+// observe joins the session as an observer. Observers are not participants,
+// they get the round events and the revealed votes without ever voting.
+func (h *Handler) observe(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["session"]
+
+	var name string
+	if err := readContent(w, r, &name); err != nil {
+		return
+	}
+
+	log.Printf("observe %q %q", id, name)
+
+	token, err := generateToken()
+	if err != nil {
+		showError(w, http.StatusInternalServerError, "token generation", err)
+		return
+	}
+
+	_, err = store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
+		for _, o := range s.Observers {
+			if o == name {
+				return nil, errAlreadyJoined
+			}
+		}
+		s.Observers = append(s.Observers, name)
+
+		if s.ObserverHashes == nil {
+			s.ObserverHashes = map[string]string{}
+		}
+		s.ObserverHashes[name] = domain.HashToken(token)
+
+		return s, nil
+	})
+
+	switch err {
+	case nil:
+		if err := showJSONWithStatus(w, http.StatusCreated, &TokenResponse{Token: token}); err != nil {
+			return
+		}
+	case errAlreadyJoined:
+		showError(w, http.StatusConflict, "already joined", nil)
+		return
+	case store.ErrVersionMismatch:
+		showError(w, http.StatusInternalServerError, "locking error, try again later", nil)
+		return
+	default:
+		showStoreError(w, err)
+		return
+	}
+}
+
+func (h *Handler) observeWS(w http.ResponseWriter, r *http.Request) {
+	session := mux.Vars(r)["session"]
+
+	if !h.authorizeObserver(w, r, session) {
+		return
+	}
+
+	log.Printf("observe ws %q", session)
+	h.serveWS(w, r, session, event.Observer, "")
+}
+
+func (h *Handler) observeEvents(w http.ResponseWriter, r *http.Request) {
+	session := mux.Vars(r)["session"]
+
+	if !h.authorizeObserver(w, r, session) {
+		return
+	}
+
+	log.Printf("observe events %q", session)
+	h.stream(w, r, session, event.Observer, "")
+}
+
+// authorizeObserver shows an error unless the request has the token of an
+// observer.
+func (h *Handler) authorizeObserver(w http.ResponseWriter, r *http.Request, session string) bool {
+	s, err := h.store.Load(session)
+	if err != nil {
+		showStoreError(w, err)
+		return false
+	}
+
+	if _, ok := s.Data.ObserverFor(tokenFrom(r)); !ok {
+		showError(w, http.StatusUnauthorized, "unauthorized", errUnauthorized)
+		return false
+	}
+	return true
+}
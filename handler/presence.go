@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/akarasz/pajthy-backend/event"
+)
+
+// IdleMessage is sent by the voters on their websocket when the user goes
+// away or comes back.
+type IdleMessage struct {
+	Idle bool
+}
+
+func (h *Handler) track(session string, participant string, ws interface{}) {
+	if t, ok := h.event.(event.Tracker); ok {
+		t.Track(session, participant, ws)
+	}
+}
+
+func (h *Handler) receive(session string, ws interface{}, msg []byte) {
+	t, ok := h.event.(event.Tracker)
+	if !ok {
+		return
+	}
+
+	var m IdleMessage
+	if err := json.Unmarshal(msg, &m); err != nil {
+		log.Printf("ws message decoding: %v", err)
+		return
+	}
+	t.SetIdle(session, ws, m.Idle)
+}
+
+// presence returns the state of every participant. It is nil when the event
+// does not track them.
+func (h *Handler) presence(session string, participants []string) map[string]event.PresenceState {
+	t, ok := h.event.(event.Tracker)
+	if !ok {
+		return nil
+	}
+
+	tracked := t.Presence(session)
+	res := make(map[string]event.PresenceState, len(participants))
+	for _, p := range participants {
+		if state, ok := tracked[p]; ok {
+			res[p] = state
+		} else {
+			res[p] = event.Offline
+		}
+	}
+	return res
+}
+
+// connected returns the participants having a connection. It is nil when the
+// event does not track them.
+func (h *Handler) connected(session string) map[string]bool {
+	t, ok := h.event.(event.Tracker)
+	if !ok {
+		return nil
+	}
+
+	res := map[string]bool{}
+	for p, state := range t.Presence(session) {
+		res[p] = state != event.Offline
+	}
+	return res
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/akarasz/pajthy-backend/event"
+)
+
+// initial returns the payloads a client gets before the live ones.
+// Reconnecting clients get the ones they missed, others the snapshot of the
+// session. When the missed payloads are not all kept anymore the client is
+// told to resync before getting the snapshot.
+//
+// It has to be called after subscribing, so nothing falls in between. The
+// payloads emitted meanwhile can come twice, see skipReplayed.
+func (h *Handler) initial(r *http.Request, session string, role event.Role) []*event.Payload {
+	since := r.URL.Query().Get("since")
+	if since == "" {
+		since = r.Header.Get("Last-Event-ID")
+	}
+	if since == "" {
+		return h.snapshot(session, role)
+	}
+
+	if missed, ok := h.missed(session, role, since); ok {
+		return missed
+	}
+
+	return append(
+		[]*event.Payload{event.NewPayload(event.Resync, nil)},
+		h.snapshot(session, role)...)
+}
+
+// missed returns the payloads emitted to the role after since. It is false
+// when they are not all kept.
+func (h *Handler) missed(session string, role event.Role, since string) ([]*event.Payload, bool) {
+	after, err := strconv.ParseUint(since, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	replayer, ok := h.event.(event.Replayer)
+	if !ok {
+		return nil, false
+	}
+
+	return replayer.Replay(session, role, after)
+}
+
+// snapshot returns the current state of the session as the role sees it.
+func (h *Handler) snapshot(session string, role event.Role) []*event.Payload {
+	s, err := h.load(session)
+	if err != nil {
+		log.Printf("snapshot of %q: %v", session, err)
+		return nil
+	}
+
+	data := &SnapshotData{
+		Choices:      s.Data.Choices,
+		Deck:         s.Data.Deck,
+		Open:         s.Data.Open,
+		Deadline:     deadlineOf(s.Data),
+		Participants: s.Data.Participants,
+	}
+	switch role {
+	case event.Controller:
+		data.Votes, data.Counts, data.Voted = controllerVotes(s.Data)
+		data.Presence = h.presence(session, s.Data.Participants)
+	case event.Voter, event.Observer:
+		if s.Data.Revealed() {
+			data.Votes = s.Data.RevealedVotes()
+		}
+	}
+
+	return []*event.Payload{event.NewPayload(event.Snapshot, data)}
+}
+
+// skipReplayed tells if the live payload was among the replayed ones, the
+// last of them having the given id.
+func skipReplayed(p *event.Payload, lastReplayed uint64) bool {
+	return p.ID != 0 && p.ID <= lastReplayed
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akarasz/pajthy-backend/domain"
+)
+
+type RoundsResponse struct {
+	Rounds []domain.Round
+}
+
+func (h *Handler) result(w http.ResponseWriter, r *http.Request) {
+	session := mux.Vars(r)["session"]
+
+	log.Printf("result %q", session)
+
+	s, err := h.load(session)
+	if err != nil {
+		showStoreError(w, err)
+		return
+	}
+
+	if s.Data.ChoicesHidden() {
+		showError(w, http.StatusConflict, "choices are hidden until the round closes", nil)
+		return
+	}
+
+	if err := showJSON(w, s.Data.Result()); err != nil {
+		return
+	}
+}
+
+func (h *Handler) rounds(w http.ResponseWriter, r *http.Request) {
+	session := mux.Vars(r)["session"]
+
+	log.Printf("rounds %q", session)
+
+	s, err := h.store.Load(session)
+	if err != nil {
+		showStoreError(w, err)
+		return
+	}
+
+	rounds := s.Data.Rounds
+	if rounds == nil {
+		rounds = []domain.Round{}
+	}
+
+	if err := showJSON(w, &RoundsResponse{Rounds: rounds}); err != nil {
+		return
+	}
+}
+
+func (h *Handler) round(w http.ResponseWriter, r *http.Request) {
+	session := mux.Vars(r)["session"]
+
+	n, err := strconv.Atoi(mux.Vars(r)["n"])
+	if err != nil {
+		showError(w, http.StatusBadRequest, "not a valid round", err)
+		return
+	}
+
+	log.Printf("round %q %d", session, n)
+
+	s, err := h.store.Load(session)
+	if err != nil {
+		showStoreError(w, err)
+		return
+	}
+
+	if n >= len(s.Data.Rounds) {
+		showError(w, http.StatusNotFound, "round not exists", errInvalidRound)
+		return
+	}
+
+	if err := showJSON(w, &s.Data.Rounds[n]); err != nil {
+		return
+	}
+}
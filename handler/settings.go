@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akarasz/pajthy-backend/domain"
+	"github.com/akarasz/pajthy-backend/store"
+)
+
+// SettingsRequest changes the settings given, the missing ones are kept.
+type SettingsRequest struct {
+	HideNames *bool
+	Anonymous *bool
+	AutoClose *domain.AutoClose
+	Quorum    *int
+	Timer     *string
+	Grace     *string
+
+	HideChoices *bool
+}
+
+func (h *Handler) updateSettings(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["session"]
+
+	var req SettingsRequest
+	if err := readContent(w, r, &req); err != nil {
+		return
+	}
+
+	log.Printf("update settings %q", id)
+
+	saved, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
+		if req.HideNames != nil {
+			s.Settings.HideNames = *req.HideNames
+		}
+		if req.Anonymous != nil && *req.Anonymous != s.Settings.Anonymous {
+			if s.Open && len(s.Votes) > 0 {
+				return nil, errVotesCast
+			}
+			// the votes of the closed round are in its history already
+			s.ClearVotes()
+			s.Settings.Anonymous = *req.Anonymous
+		}
+		if req.AutoClose != nil {
+			s.Settings.AutoClose = *req.AutoClose
+		}
+		if req.Quorum != nil {
+			s.Settings.Quorum = *req.Quorum
+		}
+		if req.Timer != nil {
+			s.Settings.Timer = *req.Timer
+		}
+		if req.Grace != nil {
+			s.Settings.Grace = *req.Grace
+		}
+		if req.HideChoices != nil {
+			s.Settings.HideChoices = *req.HideChoices
+		}
+		if err := s.Settings.Validate(); err != nil {
+			return nil, err
+		}
+
+		return s, nil
+	})
+
+	switch err {
+	case nil:
+		if err := showJSONWithStatus(w, http.StatusAccepted, &saved.Settings); err != nil {
+			return
+		}
+	case domain.ErrInvalidSettings:
+		showError(w, http.StatusBadRequest, "not valid settings", nil)
+		return
+	case errVotesCast:
+		showError(w, http.StatusConflict, "votes are already cast", nil)
+		return
+	case store.ErrVersionMismatch:
+		showError(w, http.StatusInternalServerError, "locking error, try again later", nil)
+		return
+	default:
+		showStoreError(w, err)
+		return
+	}
+}
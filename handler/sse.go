@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akarasz/pajthy-backend/event"
+)
+
+// events streams the voter events as server-sent events for the clients not
+// able to use websockets.
+func (h *Handler) events(w http.ResponseWriter, r *http.Request) {
+	session := mux.Vars(r)["session"]
+
+	s, err := h.store.Load(session)
+	if err != nil {
+		showStoreError(w, err)
+		return
+	}
+
+	participant, ok := s.Data.ParticipantFor(tokenFrom(r))
+	if !ok {
+		showError(w, http.StatusUnauthorized, "unauthorized", errUnauthorized)
+		return
+	}
+
+	log.Printf("events %q", session)
+	h.stream(w, r, session, event.Voter, participant)
+}
+
+func (h *Handler) controlEvents(w http.ResponseWriter, r *http.Request) {
+	session := mux.Vars(r)["session"]
+
+	if _, err := h.store.Load(session); err != nil {
+		showStoreError(w, err)
+		return
+	}
+
+	log.Printf("control events %q", session)
+	h.stream(w, r, session, event.Controller, "")
+}
+
+// stream sends the payloads of the subscription until the client goes away.
+// Voters are tracked as the given participant.
+// The snapshot or the payloads missed by a reconnecting client are sent first.
+func (h *Handler) stream(w http.ResponseWriter, r *http.Request, session string, role event.Role, participant string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		showError(w, http.StatusInternalServerError, "streaming unsupported", nil)
+		return
+	}
+
+	// the request identifies the subscriber as the websocket does for ws
+	c, err := h.event.Subscribe(session, role, r)
+	if err != nil {
+		showError(w, http.StatusInternalServerError, "unable to subscribe", err)
+		return
+	}
+	defer h.event.Unsubscribe(session, r)
+
+	if participant != "" {
+		h.track(session, participant, r)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var replayed uint64
+	for _, p := range h.initial(r, session, role) {
+		if err := writeEvent(w, p); err != nil {
+			return
+		}
+		replayed = p.ID
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(pingPeriod)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case msg, ok := <-c:
+			if !ok {
+				return
+			}
+			if skipReplayed(msg, replayed) {
+				continue
+			}
+			if err := writeEvent(w, msg); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, p *event.Payload) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	if p.ID != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", p.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -11,8 +12,11 @@ import (
 )
 
 type ChoicesResponse struct {
-	Choices []string
-	Open    bool
+	Choices  []string
+	Deck     []domain.Card `json:",omitempty"`
+	Open     bool
+	Deadline *time.Time `json:",omitempty"`
+	Item     *domain.Item
 }
 
 func (h *Handler) choices(w http.ResponseWriter, r *http.Request) {
@@ -20,7 +24,7 @@ func (h *Handler) choices(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("choices %q", session)
 
-	ss, err := h.store.Load(session)
+	ss, err := h.load(session)
 	if err != nil {
 		showStoreError(w, err)
 		return
@@ -28,8 +32,11 @@ func (h *Handler) choices(w http.ResponseWriter, r *http.Request) {
 	s := ss.Data
 
 	res := &ChoicesResponse{
-		Choices: s.Choices,
-		Open:    s.Open,
+		Choices:  s.Choices,
+		Deck:     s.Deck,
+		Open:     s.Open,
+		Deadline: deadlineOf(s),
+		Item:     s.CurrentItem(),
 	}
 
 	if err := showJSON(w, res); err != nil {
@@ -45,12 +52,20 @@ func (h *Handler) vote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	token := tokenFrom(r)
+
 	log.Printf("vote %q %q", id, v)
 
+	h.closeExpired(id)
+	connected := h.connected(id)
+
+	var closing time.Time
 	saved, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
-		if !s.Open {
+		now := time.Now()
+		if !s.Open || s.Expired(now) {
 			return nil, errClosedSession
 		}
+		closing = s.Closing
 
 		hasVoter := false
 		for _, p := range s.Participants {
@@ -63,20 +78,16 @@ func (h *Handler) vote(w http.ResponseWriter, r *http.Request) {
 			return nil, errInvalidParticipant
 		}
 
-		hasChoice := false
-		for _, c := range s.Choices {
-			if c == v.Choice {
-				hasChoice = true
-				break
-			}
+		if !s.IsParticipant(v.Participant, token) {
+			return nil, errUnauthorized
 		}
-		if !hasChoice {
+
+		if !s.ValidChoice(v.Choice) {
 			return nil, errInvalidChoice
 		}
 
-		s.Votes[v.Participant] = v.Choice
-
-		s.Open = len(s.Votes) < len(s.Participants)
+		s.CastVote(v.Participant, token, v.Choice)
+		s.Settle(now, connected)
 
 		return s, nil
 	})
@@ -90,6 +101,9 @@ func (h *Handler) vote(w http.ResponseWriter, r *http.Request) {
 	case errInvalidParticipant:
 		showError(w, http.StatusBadRequest, "not a valid participant", nil)
 		return
+	case errUnauthorized:
+		showError(w, http.StatusUnauthorized, "unauthorized", nil)
+		return
 	case errInvalidChoice:
 		showError(w, http.StatusBadRequest, "not a valid choice", nil)
 		return
@@ -101,10 +115,60 @@ func (h *Handler) vote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.emitVote(id, saved.Votes)
-	if !saved.Open {
-		h.emitVoteDisabled(id)
+	h.emitVote(id, saved)
+	h.emitSettled(id, saved, true, closing)
+}
+
+// retractVote withdraws the vote of the participant the token was issued to
+// while the round is open.
+func (h *Handler) retractVote(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["session"]
+
+	token := tokenFrom(r)
+
+	log.Printf("retract vote %q", id)
+
+	h.closeExpired(id)
+	connected := h.connected(id)
+
+	var closing time.Time
+	saved, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
+		now := time.Now()
+		if !s.Open || s.Expired(now) {
+			return nil, errClosedSession
+		}
+		closing = s.Closing
+
+		participant, ok := s.ParticipantFor(token)
+		if !ok {
+			return nil, errUnauthorized
+		}
+
+		s.RetractVote(participant, token)
+		s.Settle(now, connected)
+
+		return s, nil
+	})
+
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errClosedSession:
+		showError(w, http.StatusBadRequest, "session is closed", nil)
+		return
+	case errUnauthorized:
+		showError(w, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	case store.ErrVersionMismatch:
+		showError(w, http.StatusInternalServerError, "locking error, try again later", nil)
+		return
+	default:
+		showStoreError(w, err)
+		return
 	}
+
+	h.emitVote(id, saved)
+	h.emitSettled(id, saved, true, closing)
 }
 
 func (h *Handler) join(w http.ResponseWriter, r *http.Request) {
@@ -117,6 +181,12 @@ func (h *Handler) join(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("join %q %q", id, name)
 
+	token, err := generateToken()
+	if err != nil {
+		showError(w, http.StatusInternalServerError, "token generation", err)
+		return
+	}
+
 	saved, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
 		for _, p := range s.Participants {
 			if p == name {
@@ -125,12 +195,19 @@ func (h *Handler) join(w http.ResponseWriter, r *http.Request) {
 		}
 		s.Participants = append(s.Participants, name)
 
+		if s.ParticipantHashes == nil {
+			s.ParticipantHashes = map[string]string{}
+		}
+		s.ParticipantHashes[name] = domain.HashToken(token)
+
 		return s, nil
 	})
 
 	switch err {
 	case nil:
-		w.WriteHeader(http.StatusCreated)
+		if err := showJSONWithStatus(w, http.StatusCreated, &TokenResponse{Token: token}); err != nil {
+			return
+		}
 	case errAlreadyJoined:
 		showError(w, http.StatusConflict, "already joined", nil)
 		return
@@ -142,5 +219,73 @@ func (h *Handler) join(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.emitParticipantsChange(id, saved.Participants)
+	h.emitParticipantsChange(id, saved)
+}
+
+// SitOutRequest tells if the participant sits out the rounds.
+type SitOutRequest struct {
+	Participant string
+	SittingOut  bool
+}
+
+// sitOut can be called by the participant or the controller on their behalf.
+// The open round is closed by the auto-close policy when everybody else has
+// voted already.
+func (h *Handler) sitOut(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["session"]
+
+	var req SitOutRequest
+	if err := readContent(w, r, &req); err != nil {
+		return
+	}
+
+	token := tokenFrom(r)
+
+	log.Printf("sit out %q %q %v", id, req.Participant, req.SittingOut)
+
+	connected := h.connected(id)
+	var open bool
+	var closing time.Time
+	saved, err := store.ReadModifyWrite(id, h.store, func(s *domain.Session) (*domain.Session, error) {
+		hasParticipant := false
+		for _, p := range s.Participants {
+			if p == req.Participant {
+				hasParticipant = true
+				break
+			}
+		}
+		if !hasParticipant {
+			return nil, errInvalidParticipant
+		}
+
+		if !s.IsController(token) && !s.IsParticipant(req.Participant, token) {
+			return nil, errUnauthorized
+		}
+
+		s.SetSittingOut(req.Participant, req.SittingOut)
+		open, closing = s.Open, s.Closing
+		s.Settle(time.Now(), connected)
+
+		return s, nil
+	})
+
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusAccepted)
+	case errInvalidParticipant:
+		showError(w, http.StatusBadRequest, "not a valid participant", nil)
+		return
+	case errUnauthorized:
+		showError(w, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	case store.ErrVersionMismatch:
+		showError(w, http.StatusInternalServerError, "locking error, try again later", nil)
+		return
+	default:
+		showStoreError(w, err)
+		return
+	}
+
+	h.emitParticipantsChange(id, saved)
+	h.emitSettled(id, saved, open, closing)
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 
+	"github.com/akarasz/pajthy-backend/domain"
 	"github.com/akarasz/pajthy-backend/event"
 	"github.com/akarasz/pajthy-backend/store"
 )
@@ -21,7 +22,7 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool { return true },
 }
 
-func (h *Handler) writer(ws *websocket.Conn, sessionID string, msgs <-chan *event.Payload) {
+func (h *Handler) writer(ws *websocket.Conn, sessionID string, msgs <-chan *event.Payload, initial []*event.Payload) {
 	pingTicker := time.NewTicker(pingPeriod)
 	defer func() {
 		h.event.Unsubscribe(sessionID, ws)
@@ -29,9 +30,24 @@ func (h *Handler) writer(ws *websocket.Conn, sessionID string, msgs <-chan *even
 		ws.Close()
 	}()
 
+	var replayed uint64
+	for _, msg := range initial {
+		if err := ws.WriteJSON(msg); err != nil {
+			return
+		}
+		replayed = msg.ID
+	}
+
 	for {
 		select {
-		case msg := <-msgs:
+		case msg, ok := <-msgs:
+			if !ok {
+				ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if skipReplayed(msg, replayed) {
+				continue
+			}
 			if err := ws.WriteJSON(msg); err != nil {
 				return
 			}
@@ -52,44 +68,50 @@ func (h *Handler) reader(ws *websocket.Conn, sessionID string) {
 	ws.SetReadDeadline(time.Now().Add(pongWait))
 	ws.SetPongHandler(func(string) error { ws.SetReadDeadline(time.Now().Add(pongWait)); return nil })
 	for {
-		_, _, err := ws.ReadMessage()
+		kind, msg, err := ws.ReadMessage()
 		if err != nil {
 			break
 		}
+		if kind == websocket.TextMessage {
+			h.receive(sessionID, ws, msg)
+		}
 	}
 }
 
 func (h *Handler) ws(w http.ResponseWriter, r *http.Request) {
 	session := mux.Vars(r)["session"]
 
-	ws, err := upgrader.Upgrade(w, r, nil)
+	s, err := h.store.Load(session)
 	if err != nil {
-		if _, ok := err.(websocket.HandshakeError); !ok {
-			showError(w, http.StatusInternalServerError, "unknown error", err)
-		}
-		return
-	}
-
-	if _, err := h.store.Load(session); err == store.ErrNotExists {
-		showError(w, http.StatusBadRequest, "session not found", err)
+		showStoreError(w, err)
 		return
 	}
 
-	c, err := h.event.Subscribe(session, event.Voter, ws)
-	if err != nil {
-		showError(w, http.StatusInternalServerError, "unable to subscribe", err)
+	participant, ok := s.Data.ParticipantFor(tokenFrom(r))
+	if !ok {
+		showError(w, http.StatusUnauthorized, "unauthorized", errUnauthorized)
 		return
 	}
 
 	log.Printf("ws %q", session)
-
-	go h.writer(ws, session, c)
-	h.reader(ws, session)
+	h.serveWS(w, r, session, event.Voter, participant)
 }
 
 func (h *Handler) controlWS(w http.ResponseWriter, r *http.Request) {
 	session := mux.Vars(r)["session"]
 
+	if _, err := h.store.Load(session); err == store.ErrNotExists {
+		showError(w, http.StatusBadRequest, "session not found", err)
+		return
+	}
+
+	log.Printf("control ws %q", session)
+	h.serveWS(w, r, session, event.Controller, "")
+}
+
+// serveWS upgrades the connection and sends the events of the role until it
+// is closed. Voters are tracked as the given participant.
+func (h *Handler) serveWS(w http.ResponseWriter, r *http.Request, session string, role event.Role, participant string) {
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		if _, ok := err.(websocket.HandshakeError); !ok {
@@ -98,61 +120,159 @@ func (h *Handler) controlWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err := h.store.Load(session); err == store.ErrNotExists {
-		showError(w, http.StatusBadRequest, "session not found", err)
-		return
-	}
-
-	c, err := h.event.Subscribe(session, event.Controller, ws)
+	c, err := h.event.Subscribe(session, role, ws)
 	if err != nil {
 		showError(w, http.StatusInternalServerError, "unable to subscribe", err)
 		return
 	}
 
-	log.Printf("control ws %q", session)
-
-	go h.writer(ws, session, c)
+	if participant != "" {
+		h.track(session, participant, ws)
+	}
+	go h.writer(ws, session, c, h.initial(r, session, role))
 	h.reader(ws, session)
 }
 
+// SnapshotData is the state of the session sent first to the subscribers.
+// Votes are sent to controllers, and to voters and observers once revealed.
+// Counts replace them for controllers of anonymous sessions, Voted while the
+// choices are hidden. Presence is sent to controllers only.
+type SnapshotData struct {
+	Choices      []string
+	Deck         []domain.Card `json:",omitempty"`
+	Open         bool
+	Deadline     *time.Time `json:",omitempty"`
+	Participants []string
+	Votes        map[string]string
+	Counts       map[string]int `json:",omitempty"`
+	Voted        []string       `json:",omitempty"`
+	Presence     map[string]event.PresenceState
+}
+
+// OpenChangedData tells if the round is open. An opened round is closed at the
+// Deadline when it has one.
 type OpenChangedData struct {
-	Open bool
+	Open     bool
+	Deadline *time.Time `json:",omitempty"`
 }
 
+// VotesChangedData has the votes by participant, or only the number of votes
+// per choice when the session is anonymous. Only the ones who voted are sent
+// while the choices are hidden.
 type VotesChangedData struct {
-	Votes map[string]string
+	Votes  map[string]string
+	Counts map[string]int `json:",omitempty"`
+	Voted  []string       `json:",omitempty"`
 }
 
 type ParticipantsChangedData struct {
 	Participants []string
+	SittingOut   []string `json:",omitempty"`
+}
+
+type ResultData struct {
+	Result *domain.Result
+}
+
+// RevealedData is the outcome of the round with the votes of everybody.
+type RevealedData struct {
+	Votes  map[string]string
+	Result *domain.Result
+}
+
+type ChoicesChangedData struct {
+	Choices []string
+	Deck    []domain.Card `json:",omitempty"`
+}
+
+type CurrentItemData struct {
+	Item *domain.Item
 }
 
-func (h *Handler) emitVoteEnabled(id string) {
-	m := &OpenChangedData{Open: true}
+type RoundArchivedData struct {
+	Index int
+	Round *domain.Round
+}
+
+func (h *Handler) emitVoteEnabled(id string, s *domain.Session) {
+	m := &OpenChangedData{Open: true, Deadline: deadlineOf(s)}
 	h.event.Emit(id, event.Voter, event.Enabled, m)
 	h.event.Emit(id, event.Controller, event.Enabled, m)
+	h.event.Emit(id, event.Observer, event.Enabled, m)
 }
 
 func (h *Handler) emitVoteDisabled(id string) {
 	m := &OpenChangedData{Open: false}
 	h.event.Emit(id, event.Voter, event.Disabled, m)
 	h.event.Emit(id, event.Controller, event.Disabled, m)
+	h.event.Emit(id, event.Observer, event.Disabled, m)
+}
+
+// emitDone sends the result to the controllers and reveals the votes to the
+// voters and the observers, without the names if the session hides them.
+func (h *Handler) emitDone(id string, s *domain.Session) {
+	result := s.Result()
+	h.event.Emit(id, event.Controller, event.Done, &ResultData{Result: result})
+
+	revealed := &RevealedData{Votes: s.RevealedVotes(), Result: result}
+	h.event.Emit(id, event.Voter, event.Done, revealed)
+	h.event.Emit(id, event.Observer, event.Done, revealed)
+}
+
+func (h *Handler) emitExpired(id string) {
+	h.event.Emit(id, event.Voter, event.Expired, nil)
+	h.event.Emit(id, event.Controller, event.Expired, nil)
+	h.event.Emit(id, event.Observer, event.Expired, nil)
 }
 
 func (h *Handler) emitReset(id string) {
 	m := &OpenChangedData{Open: false}
 	h.event.Emit(id, event.Voter, event.Reset, m)
 	h.event.Emit(id, event.Controller, event.Reset, m)
+	h.event.Emit(id, event.Observer, event.Reset, m)
+}
+
+func (h *Handler) emitVote(id string, s *domain.Session) {
+	votes, counts, voted := controllerVotes(s)
+	h.event.Emit(id, event.Controller, event.Vote, &VotesChangedData{
+		Votes:  votes,
+		Counts: counts,
+		Voted:  voted,
+	})
+}
+
+// controllerVotes returns the votes as the controllers see them: by
+// participant, counted per choice for anonymous sessions, or only who has
+// voted while the choices are hidden.
+func controllerVotes(s *domain.Session) (map[string]string, map[string]int, []string) {
+	if s.ChoicesHidden() {
+		return nil, nil, s.Voted()
+	}
+	return s.NamedVotes(), s.AnonymousCounts(), nil
+}
+
+func (h *Handler) emitChoicesChange(id string, s *domain.Session) {
+	m := &ChoicesChangedData{Choices: s.Choices, Deck: s.Deck}
+	h.event.Emit(id, event.Voter, event.ChoicesChange, m)
+	h.event.Emit(id, event.Controller, event.ChoicesChange, m)
+	h.event.Emit(id, event.Observer, event.ChoicesChange, m)
+}
+
+func (h *Handler) emitRoundArchived(id string, index int, round *domain.Round) {
+	h.event.Emit(id, event.Controller, event.RoundArchived, &RoundArchivedData{Index: index, Round: round})
 }
 
-func (h *Handler) emitVote(id string, votes map[string]string) {
-	h.event.Emit(id, event.Controller, event.Vote, &VotesChangedData{Votes: votes})
+func (h *Handler) emitCurrentItem(id string, item *domain.Item) {
+	m := &CurrentItemData{Item: item}
+	h.event.Emit(id, event.Voter, event.CurrentItem, m)
+	h.event.Emit(id, event.Controller, event.CurrentItem, m)
+	h.event.Emit(id, event.Observer, event.CurrentItem, m)
 }
 
-func (c *Handler) emitParticipantsChange(id string, participants []string) {
+func (c *Handler) emitParticipantsChange(id string, s *domain.Session) {
 	c.event.Emit(
 		id,
 		event.Controller,
 		event.ParticipantsChange,
-		&ParticipantsChangedData{Participants: participants})
+		&ParticipantsChangedData{Participants: s.Participants, SittingOut: s.SittingOut})
 }
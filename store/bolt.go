@@ -0,0 +1,133 @@
+package store
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/akarasz/pajthy-backend/domain"
+)
+
+var boltBucket = []byte("sessions")
+
+// Bolt keeps the sessions in an embedded database file in the given
+// directory so they survive restarts of a single node deployment.
+type Bolt struct {
+	db      *bolt.DB
+	options options
+}
+
+func NewBolt(dir string, opts ...Option) (*Bolt, error) {
+	db, err := bolt.Open(filepath.Join(dir, "pajthy.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Bolt{
+		db:      db,
+		options: newOptions(opts),
+	}, nil
+}
+
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+func (b *Bolt) Load(id string) (*Session, error) {
+	var res *Session
+	err := b.db.View(func(tx *bolt.Tx) error {
+		var err error
+		res, err = boltGet(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if b.options.expired(res) {
+		return nil, ErrNotExists
+	}
+
+	return res, nil
+}
+
+func (b *Bolt) Save(id string, item *domain.Session, version ...uuid.UUID) error {
+	if len(version) > 1 {
+		return ErrVersionMismatch
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		current, err := boltGet(tx, id)
+		if err != nil && err != ErrNotExists {
+			return err
+		}
+
+		exists := err == nil
+		if (exists && (len(version) == 0 || current.Version != version[0])) || (!exists && len(version) != 0) {
+			return ErrVersionMismatch
+		}
+
+		data, err := json.Marshal(WithNewVersion(item))
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(boltBucket).Put([]byte(id), data)
+	})
+}
+
+func (b *Bolt) Expire() error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+
+		expired := [][]byte{}
+		err := bucket.ForEach(func(k, v []byte) error {
+			var s Session
+			if err := json.Unmarshal(v, &s); err != nil {
+				return err
+			}
+
+			if b.options.expired(&s) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func boltGet(tx *bolt.Tx, id string) (*Session, error) {
+	raw := tx.Bucket(boltBucket).Get([]byte(id))
+	if raw == nil {
+		return nil, ErrNotExists
+	}
+
+	var res Session
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,46 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/akarasz/pajthy-backend/domain"
+	"github.com/akarasz/pajthy-backend/store"
+)
+
+func TestBolt(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := store.NewBolt(dir)
+	require.NoError(t, err)
+	defer s.Close()
+
+	expiring, err := store.NewBolt(t.TempDir(), store.WithTTL(testTTL))
+	require.NoError(t, err)
+	defer expiring.Close()
+
+	suite.Run(t, &Suite{Subject: s, Expiring: expiring})
+}
+
+func TestBolt_Reopen(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := store.NewBolt(dir)
+	require.NoError(t, err)
+
+	created := domain.NewSession()
+	created.Choices = []string{"one", "two"}
+	require.NoError(t, s.Save("reopenID", created))
+	require.NoError(t, s.Close())
+
+	// sessions are kept after reopening the same directory
+	reopened, err := store.NewBolt(dir)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	got, err := reopened.Load("reopenID")
+	require.NoError(t, err)
+	require.Exactly(t, created, got.Data)
+}
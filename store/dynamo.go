@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -15,15 +16,17 @@ import (
 )
 
 type DynamoDB struct {
-	client *dynamodb.Client
-	table  *string
+	client  *dynamodb.Client
+	table   *string
+	options options
 }
 
-func NewDynamoDB(c *aws.Config, table string) *DynamoDB {
+func NewDynamoDB(c *aws.Config, table string, opts ...Option) *DynamoDB {
 	client := dynamodb.NewFromConfig(*c)
 	return &DynamoDB{
-		client: client,
-		table:  aws.String(table),
+		client:  client,
+		table:   aws.String(table),
+		options: newOptions(opts),
 	}
 }
 
@@ -40,13 +43,20 @@ func newDynamoKey(id string) *dynamoKey {
 type dynamoItem struct {
 	*dynamoKey
 	*Session
+
+	// TTL is the epoch second after which DynamoDB can reap the item.
+	TTL int64 `dynamodbav:"ttl,omitempty"`
 }
 
-func newDynamoItem(id string, s *Session) *dynamoItem {
-	return &dynamoItem{
-		newDynamoKey(id),
-		s,
+func newDynamoItem(id string, s *Session, ttl time.Duration) *dynamoItem {
+	res := &dynamoItem{
+		dynamoKey: newDynamoKey(id),
+		Session:   s,
+	}
+	if ttl > 0 {
+		res.TTL = s.Modified.Add(ttl).Unix()
 	}
+	return res
 }
 
 func (d *DynamoDB) Load(id string) (*Session, error) {
@@ -70,14 +80,19 @@ func (d *DynamoDB) Load(id string) (*Session, error) {
 	}
 
 	item := dynamoItem{
-		&dynamoKey{},
-		&Session{},
+		dynamoKey: &dynamoKey{},
+		Session:   &Session{},
 	}
 	err = attributevalue.UnmarshalMap(res.Item, &item)
 	if err != nil {
 		return nil, err
 	}
 
+	// reaping happens eventually, expired items can still be around
+	if d.options.expired(item.Session) {
+		return nil, ErrNotExists
+	}
+
 	return item.Session, nil
 }
 
@@ -86,7 +101,7 @@ func (d *DynamoDB) Save(id string, item *domain.Session, version ...uuid.UUID) e
 		return ErrVersionMismatch
 	}
 
-	data, err := attributevalue.MarshalMap(newDynamoItem(id, WithNewVersion(item)))
+	data, err := attributevalue.MarshalMap(newDynamoItem(id, WithNewVersion(item), d.options.ttl))
 	if err != nil {
 		return err
 	}
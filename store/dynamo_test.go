@@ -78,5 +78,6 @@ func TestDynamoDB(t *testing.T) {
 	require.NoError(t, err)
 
 	s := store.NewDynamoDB(&c, "testPajthy")
-	suite.Run(t, &Suite{Subject: s})
+	expiring := store.NewDynamoDB(&c, "testPajthy", store.WithTTL(testTTL))
+	suite.Run(t, &Suite{Subject: s, Expiring: expiring})
 }
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"encoding/json"
 	"sync"
 
 	"github.com/google/uuid"
@@ -9,14 +10,16 @@ import (
 )
 
 type InMemory struct {
-	repo map[string]*Session
+	repo    map[string]*Session
+	options options
 
 	sync.RWMutex
 }
 
-func NewInMemory() *InMemory {
+func NewInMemory(opts ...Option) *InMemory {
 	return &InMemory{
-		repo: map[string]*Session{},
+		repo:    map[string]*Session{},
+		options: newOptions(opts),
 	}
 }
 
@@ -25,11 +28,20 @@ func (im *InMemory) Load(id string) (*Session, error) {
 	defer im.RUnlock()
 
 	saved, ok := im.repo[id]
-	if !ok {
+	if !ok || im.options.expired(saved) {
 		return nil, ErrNotExists
 	}
 
-	return saved, nil
+	data, err := copySession(saved.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		Data:     data,
+		Version:  saved.Version,
+		Modified: saved.Modified,
+	}, nil
 }
 
 func (im *InMemory) Save(id string, item *domain.Session, version ...uuid.UUID) error {
@@ -37,6 +49,13 @@ func (im *InMemory) Save(id string, item *domain.Session, version ...uuid.UUID)
 		return ErrVersionMismatch
 	}
 
+	// a copy is kept so the callers still holding the session can not change
+	// it behind the version check, like with the other stores
+	data, err := copySession(item)
+	if err != nil {
+		return err
+	}
+
 	im.Lock()
 	defer im.Unlock()
 
@@ -45,6 +64,32 @@ func (im *InMemory) Save(id string, item *domain.Session, version ...uuid.UUID)
 		return ErrVersionMismatch
 	}
 
-	im.repo[id] = WithNewVersion(item)
+	im.repo[id] = WithNewVersion(data)
 	return nil
 }
+
+func (im *InMemory) Expire() error {
+	im.Lock()
+	defer im.Unlock()
+
+	for id, s := range im.repo {
+		if im.options.expired(s) {
+			delete(im.repo, id)
+		}
+	}
+
+	return nil
+}
+
+func copySession(s *domain.Session) (*domain.Session, error) {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var res domain.Session
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
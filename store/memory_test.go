@@ -3,12 +3,42 @@ package store_test
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/akarasz/pajthy-backend/domain"
 	"github.com/akarasz/pajthy-backend/store"
 )
 
 func TestInMemory(t *testing.T) {
+	suite.Run(t, &Suite{
+		Subject:  store.NewInMemory(),
+		Expiring: store.NewInMemory(store.WithTTL(testTTL)),
+	})
+}
+
+func TestInMemory_Copies(t *testing.T) {
 	s := store.NewInMemory()
-	suite.Run(t, &Suite{Subject: s})
+
+	saved := domain.NewSession()
+	saved.Votes["Alice"] = "1"
+	require.NoError(t, s.Save("id", saved))
+
+	// changing the saved session does not change the stored one
+	saved.Votes["Alice"] = "2"
+
+	loaded, err := s.Load("id")
+	require.NoError(t, err)
+	assert.Exactly(t, "1", loaded.Data.Votes["Alice"])
+
+	// neither does changing a loaded one without saving it
+	loaded.Data.Votes["Bob"] = "3"
+	loaded.Data.Open = true
+
+	again, err := s.Load("id")
+	require.NoError(t, err)
+	assert.Exactly(t, map[string]string{"Alice": "1"}, again.Data.Votes)
+	assert.False(t, again.Data.Open)
+	assert.Exactly(t, loaded.Version, again.Version)
 }
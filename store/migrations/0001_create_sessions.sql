@@ -0,0 +1,5 @@
+CREATE TABLE sessions (
+	id      VARCHAR(64) PRIMARY KEY,
+	version VARCHAR(36) NOT NULL,
+	data    TEXT        NOT NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE sessions ADD COLUMN modified BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+
+	"github.com/akarasz/pajthy-backend/domain"
+)
+
+// redisSave writes the session only if the stored version matches the
+// expected one, or when no version is expected, only if the session does not
+// exist yet. Expiring the sessions is left to redis.
+var redisSave = redis.NewScript(`
+local current = redis.call("HGET", KEYS[1], "version")
+if ARGV[1] == "" then
+	if current then
+		return 0
+	end
+elseif current ~= ARGV[1] then
+	return 0
+end
+redis.call("HSET", KEYS[1], "version", ARGV[2], "data", ARGV[3], "modified", ARGV[4])
+if tonumber(ARGV[5]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[5])
+end
+return 1
+`)
+
+type Redis struct {
+	client  *redis.Client
+	options options
+}
+
+func NewRedis(o *redis.Options, opts ...Option) *Redis {
+	return &Redis{
+		client:  redis.NewClient(o),
+		options: newOptions(opts),
+	}
+}
+
+func redisKey(id string) string {
+	return "pajthy:session:" + id
+}
+
+func (r *Redis) Load(id string) (*Session, error) {
+	fields, err := r.client.HGetAll(context.TODO(), redisKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		return nil, ErrNotExists
+	}
+
+	version, err := uuid.Parse(fields["version"])
+	if err != nil {
+		return nil, err
+	}
+
+	var data domain.Session
+	if err := json.Unmarshal([]byte(fields["data"]), &data); err != nil {
+		return nil, err
+	}
+
+	res := &Session{
+		Data:    &data,
+		Version: version,
+	}
+	if modified, err := strconv.ParseInt(fields["modified"], 10, 64); err == nil {
+		res.Modified = time.Unix(0, modified)
+	}
+
+	return res, nil
+}
+
+func (r *Redis) Save(id string, item *domain.Session, version ...uuid.UUID) error {
+	if len(version) > 1 {
+		return ErrVersionMismatch
+	}
+
+	expected := ""
+	if len(version) == 1 {
+		expected = version[0].String()
+	}
+
+	s := WithNewVersion(item)
+	data, err := json.Marshal(s.Data)
+	if err != nil {
+		return err
+	}
+
+	ok, err := redisSave.Run(
+		context.TODO(),
+		r.client,
+		[]string{redisKey(id)},
+		expected, s.Version.String(), data, s.Modified.UnixNano(), r.options.ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+
+	if ok == 0 {
+		return ErrVersionMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/akarasz/pajthy-backend/domain"
+	"github.com/akarasz/pajthy-backend/store"
+)
+
+func TestRedis(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	defer server.Close()
+
+	s := store.NewRedis(&redis.Options{Addr: server.Addr()})
+	suite.Run(t, &Suite{Subject: s})
+}
+
+func TestRedis_Expiry(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	defer server.Close()
+
+	s := store.NewRedis(&redis.Options{Addr: server.Addr()}, store.WithTTL(time.Minute))
+
+	require.NoError(t, s.Save("expiryID", domain.NewSession()))
+
+	// the key expires together with the session
+	assert.Exactly(t, time.Minute, server.TTL("pajthy:session:expiryID"))
+
+	server.FastForward(2 * time.Minute)
+	_, err = s.Load("expiryID")
+	assert.Exactly(t, store.ErrNotExists, err)
+}
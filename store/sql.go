@@ -0,0 +1,182 @@
+package store
+
+import (
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/akarasz/pajthy-backend/domain"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// SQL keeps the sessions in a relational database. Queries are written to
+// work with both SQLite and PostgreSQL.
+type SQL struct {
+	db      *sql.DB
+	options options
+}
+
+// NewSQL applies the missing schema migrations on the database before
+// returning the store.
+func NewSQL(db *sql.DB, opts ...Option) (*SQL, error) {
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+
+	return &SQL{
+		db:      db,
+		options: newOptions(opts),
+	}, nil
+}
+
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`)
+	if err != nil {
+		return err
+	}
+
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, f := range files {
+		version, err := strconv.Atoi(strings.SplitN(strings.TrimPrefix(f, "migrations/"), "_", 2)[0])
+		if err != nil {
+			return err
+		}
+
+		if err := migrateTo(db, version, f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func migrateTo(db *sql.DB, version int, file string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var applied int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE version = $1`, version).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	script, err := migrations.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(string(script)); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQL) Load(id string) (*Session, error) {
+	var version, data string
+	var modified int64
+	err := s.db.QueryRow(`SELECT version, data, modified FROM sessions WHERE id = $1`, id).
+		Scan(&version, &data, &modified)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := uuid.Parse(version)
+	if err != nil {
+		return nil, err
+	}
+
+	var d domain.Session
+	if err := json.Unmarshal([]byte(data), &d); err != nil {
+		return nil, err
+	}
+
+	res := &Session{
+		Data:    &d,
+		Version: v,
+	}
+	if modified > 0 {
+		res.Modified = time.Unix(0, modified)
+	}
+
+	if s.options.expired(res) {
+		return nil, ErrNotExists
+	}
+
+	return res, nil
+}
+
+func (s *SQL) Save(id string, item *domain.Session, version ...uuid.UUID) error {
+	if len(version) > 1 {
+		return ErrVersionMismatch
+	}
+
+	saved := WithNewVersion(item)
+	data, err := json.Marshal(saved.Data)
+	if err != nil {
+		return err
+	}
+
+	var res sql.Result
+	if len(version) == 0 {
+		res, err = s.db.Exec(
+			`INSERT INTO sessions (id, version, data, modified) VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO NOTHING`,
+			id, saved.Version.String(), string(data), saved.Modified.UnixNano())
+	} else {
+		res, err = s.db.Exec(
+			`UPDATE sessions SET version = $1, data = $2, modified = $3 WHERE id = $4 AND version = $5`,
+			saved.Version.String(), string(data), saved.Modified.UnixNano(), id, version[0].String())
+	}
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrVersionMismatch
+	}
+
+	return nil
+}
+
+func (s *SQL) Expire() error {
+	if s.options.ttl <= 0 {
+		return nil
+	}
+
+	_, err := s.db.Exec(
+		`DELETE FROM sessions WHERE modified > 0 AND modified < $1`,
+		s.options.cutoff().UnixNano())
+	return err
+}
@@ -0,0 +1,31 @@
+package store_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/akarasz/pajthy-backend/store"
+)
+
+func TestSQL(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "pajthy.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	s, err := store.NewSQL(db)
+	require.NoError(t, err)
+
+	// migrating an already migrated database is a noop
+	_, err = store.NewSQL(db)
+	require.NoError(t, err)
+
+	expiring, err := store.NewSQL(db, store.WithTTL(testTTL))
+	require.NoError(t, err)
+
+	suite.Run(t, &Suite{Subject: s, Expiring: expiring})
+}
@@ -19,18 +19,59 @@ type Store interface {
 	Save(id string, item *domain.Session, version ...uuid.UUID) error
 }
 
+// Expirer is implemented by the stores that have to be told to remove the
+// expired sessions.
+type Expirer interface {
+	Expire() error
+}
+
 type Session struct {
-	Data    *domain.Session
-	Version uuid.UUID
+	Data     *domain.Session
+	Version  uuid.UUID
+	Modified time.Time
 }
 
 func WithNewVersion(data *domain.Session) *Session {
 	return &Session{
-		Data:    data,
-		Version: uuid.Must(uuid.NewRandom()),
+		Data:     data,
+		Version:  uuid.Must(uuid.NewRandom()),
+		Modified: time.Now(),
+	}
+}
+
+type Option func(*options)
+
+type options struct {
+	ttl time.Duration
+}
+
+// WithTTL makes the sessions expire after being inactive for the given
+// duration.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
 	}
 }
 
+func newOptions(opts []Option) options {
+	var res options
+	for _, o := range opts {
+		o(&res)
+	}
+	return res
+}
+
+// expired tells if the session was inactive for too long. Sessions saved
+// before tracking the activity never expire.
+func (o options) expired(s *Session) bool {
+	return o.ttl > 0 && !s.Modified.IsZero() && time.Since(s.Modified) > o.ttl
+}
+
+// cutoff returns the time before which the sessions are considered expired.
+func (o options) cutoff() time.Time {
+	return time.Now().Add(-o.ttl)
+}
+
 func ReadModifyWrite(id string, s Store, modify func(*domain.Session) (*domain.Session, error)) (*domain.Session, error) {
 	for retry := 0; retry < 5; retry++ {
 		loaded, err := s.Load(id)
@@ -1,6 +1,8 @@
 package store_test
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/suite"
 
@@ -8,10 +10,15 @@ import (
 	"github.com/akarasz/pajthy-backend/store"
 )
 
+const testTTL = 50 * time.Millisecond
+
 type Suite struct {
 	suite.Suite
 
 	Subject store.Store
+
+	// Expiring is optional, it should be created with testTTL.
+	Expiring store.Store
 }
 
 func (t *Suite) TestLoad() {
@@ -66,3 +73,34 @@ func (t *Suite) TestSave() {
 	t.Require().NoError(err)
 	t.Exactly(modified, saved.Data)
 }
+
+func (t *Suite) TestExpiry() {
+	s := t.Expiring
+	if s == nil {
+		t.T().Skip("no expiring store")
+	}
+
+	t.Require().NoError(s.Save("expiryID", domain.NewSession()))
+
+	// active sessions can be loaded
+	saved, err := s.Load("expiryID")
+	t.Require().NoError(err)
+
+	// saving extends the lifetime of the session
+	time.Sleep(testTTL / 2)
+	t.Require().NoError(s.Save("expiryID", saved.Data, saved.Version))
+	time.Sleep(testTTL / 2)
+	_, err = s.Load("expiryID")
+	t.NoError(err)
+
+	// inactive sessions are gone
+	time.Sleep(testTTL)
+	_, err = s.Load("expiryID")
+	t.Exactly(store.ErrNotExists, err)
+
+	// expiring removes them from the underlying storage
+	if e, ok := s.(store.Expirer); ok {
+		t.NoError(e.Expire())
+		t.NoError(s.Save("expiryID", domain.NewSession()))
+	}
+}